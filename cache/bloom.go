@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// 默认的共享布隆过滤器位图键前缀
+const defaultBloomKey = "bloom:short_code"
+
+// RedisBloomFilter 基于Redis位图（SETBIT/GETBIT）的共享布隆过滤器，所有实例看到一致的成员集合。
+// 与本地过滤器使用相同的位置计算，Redis不可用时 Contains 返回true，退化为回源查询
+type RedisBloomFilter struct {
+	client  *redis.Client
+	breaker *CircuitBreaker
+	key     string
+	m       uint64
+	k       uint64
+
+	// pending 写入失败的元素，Redis恢复后（下次写入或查询成功时）补写，避免故障期间创建的短码被拒绝。
+	// 不设上限：丢弃任何一个都会让仍被视为完整的过滤器误判该短码不存在
+	mu      sync.Mutex
+	pending []string
+	// failedAdds 清除预热标记之前写入失败的次数。待重试列表在重启后丢失，其他实例也可能在故障期间退出，
+	// 因此Redis恢复后清除预热标记，让之后启动的实例重新全量预热
+	failedAdds int
+}
+
+// NewRedisBloomFilter 创建共享布隆过滤器，位数和哈希函数个数按预期元素数和假阳性率计算。
+// 实际的位图键带上m和k，参数变化后会使用新的位图重新预热
+func NewRedisBloomFilter(client *redis.Client, key string, expectedItems uint64, falsePositiveRate float64) *RedisBloomFilter {
+	if key == "" {
+		key = defaultBloomKey
+	}
+	m, k := utils.OptimalBloomParams(expectedItems, falsePositiveRate)
+	return &RedisBloomFilter{
+		client:  client,
+		breaker: NewCircuitBreaker("redis bloom filter", 0, 0),
+		key:     fmt.Sprintf("%s:%d:%d", key, m, k),
+		m:       m,
+		k:       k,
+	}
+}
+
+// Add 添加元素，失败时暂存到待重试列表
+func (f *RedisBloomFilter) Add(item string) {
+	f.AddAll([]string{item})
+}
+
+// AddAll 通过一次管道批量添加元素（连同待重试列表），失败时暂存到待重试列表并返回错误；
+// 写入失败后第一次写入成功时同时清除预热标记
+func (f *RedisBloomFilter) AddAll(newItems []string) error {
+	f.mu.Lock()
+	items := append(f.pending, newItems...)
+	f.pending = nil
+	failedAdds := f.failedAdds
+	f.mu.Unlock()
+
+	if len(items) == 0 {
+		return nil
+	}
+	if err := f.add(items, failedAdds > 0); err != nil {
+		log.Printf("写入共享布隆过滤器失败，稍后重试: %v", err)
+
+		f.mu.Lock()
+		f.pending = append(f.pending, items...)
+		f.failedAdds++
+		f.mu.Unlock()
+		return err
+	}
+
+	if failedAdds > 0 {
+		log.Printf("共享布隆过滤器曾写入失败，已清除预热标记，之后启动的实例将重新全量预热")
+		f.mu.Lock()
+		// 期间又有写入失败时保留计数，下次写入成功时再清除一次
+		if f.failedAdds == failedAdds {
+			f.failedAdds = 0
+		}
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+// add 通过管道批量写入元素，unmark 为true时同时删除预热标记
+func (f *RedisBloomFilter) add(items []string, unmark bool) error {
+	if !f.breaker.Allow() {
+		return ErrUnavailable
+	}
+
+	_, err := f.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, item := range items {
+			for _, pos := range utils.BloomPositions(item, f.m, f.k) {
+				pipe.SetBit(ctx, f.key, int64(pos), 1)
+			}
+		}
+		pipe.IncrBy(ctx, f.itemsKey(), int64(len(items)))
+		if unmark {
+			pipe.Del(ctx, f.warmedKey())
+		}
+		return nil
+	})
+	if err != nil {
+		f.breaker.Failure()
+		return err
+	}
+	f.breaker.Success()
+	return nil
+}
+
+// Contains 检查元素是否可能存在。Redis恢复后有待重试的元素时补写，本次查询可能未包含它们，因此放行
+func (f *RedisBloomFilter) Contains(item string) bool {
+	if !f.breaker.Allow() {
+		return true
+	}
+
+	positions := utils.BloomPositions(item, f.m, f.k)
+	cmds := make([]*redis.IntCmd, len(positions))
+	_, err := f.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, pos := range positions {
+			cmds[i] = pipe.GetBit(ctx, f.key, int64(pos))
+		}
+		return nil
+	})
+	if err != nil {
+		f.breaker.Failure()
+		return true
+	}
+	f.breaker.Success()
+
+	f.mu.Lock()
+	hasPending := len(f.pending) > 0
+	f.mu.Unlock()
+	if hasPending {
+		f.AddAll(nil)
+		return true
+	}
+
+	for _, cmd := range cmds {
+		if cmd.Val() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats 返回统计信息，置位数由 BITCOUNT 统计
+func (f *RedisBloomFilter) Stats() utils.BloomFilterStats {
+	setBits, _ := f.client.BitCount(ctx, f.key, nil).Result()
+	items, _ := f.client.Get(ctx, f.itemsKey()).Uint64()
+	return utils.NewBloomFilterStats(f.m, f.k, uint64(setBits), items)
+}
+
+// Warmed 是否已有实例完成过全量预热
+func (f *RedisBloomFilter) Warmed() (bool, error) {
+	err := f.client.Get(ctx, f.warmedKey()).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MarkWarmed 标记已完成全量预热，之后启动的实例无需再次预热
+func (f *RedisBloomFilter) MarkWarmed() error {
+	return f.client.Set(ctx, f.warmedKey(), 1, 0).Err()
+}
+
+func (f *RedisBloomFilter) itemsKey() string {
+	return f.key + ":items"
+}
+
+func (f *RedisBloomFilter) warmedKey() string {
+	return f.key + ":warmed"
+}
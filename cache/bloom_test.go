@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisBloomFilterFailedAddKeepsPending(t *testing.T) {
+	// 连接不可用的地址，写入失败后暂存并记录失败，熔断期间 Contains 放行
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	f := NewRedisBloomFilter(client, "", 1000, 0.01)
+
+	if err := f.AddAll([]string{"abc1234", "def5678"}); err == nil {
+		t.Errorf("AddAll() error = nil, want write failure")
+	}
+	f.Add("ghi9012")
+
+	f.mu.Lock()
+	pending, failedAdds := len(f.pending), f.failedAdds
+	f.mu.Unlock()
+	if pending != 3 {
+		t.Errorf("pending = %d, want 3", pending)
+	}
+	if failedAdds != 2 {
+		t.Errorf("failedAdds = %d, want 2", failedAdds)
+	}
+	if !f.Contains("unknown") {
+		t.Errorf("Contains() = false while Redis unavailable, want true")
+	}
+}
+
+func TestRedisBloomFilterKeepsAllPending(t *testing.T) {
+	// 待重试列表不设上限，丢弃任何一个都会导致恢复后误判
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	f := NewRedisBloomFilter(client, "", 1000, 0.01)
+
+	const batches, batchSize = 3, 5000
+	for i := 0; i < batches; i++ {
+		items := make([]string, batchSize)
+		for j := range items {
+			items[j] = strconv.Itoa(i*batchSize + j)
+		}
+		if err := f.AddAll(items); err == nil {
+			t.Fatalf("AddAll() error = nil, want write failure")
+		}
+	}
+
+	f.mu.Lock()
+	pending := len(f.pending)
+	f.mu.Unlock()
+	if pending != batches*batchSize {
+		t.Errorf("pending = %d, want %d", pending, batches*batchSize)
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	stateClosed   breakerState = iota // 正常放行
+	stateOpen                         // 熔断，直接拒绝
+	stateHalfOpen                     // 半开，只放行一个探测请求
+)
+
+// CircuitBreaker 熔断器：连续失败达到阈值后熔断一段时间，到期后放行一个探测请求，成功则恢复
+type CircuitBreaker struct {
+	name        string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker 创建熔断器，threshold 和 openTimeout 未配置时使用默认值
+func NewCircuitBreaker(name string, threshold int, openTimeout time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if openTimeout <= 0 {
+		openTimeout = 10 * time.Second
+	}
+	return &CircuitBreaker{
+		name:        name,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+	}
+}
+
+// Allow 判断当前是否允许请求通过
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		// 熔断到期，放行一个探测请求
+		b.state = stateHalfOpen
+		return true
+	case stateHalfOpen:
+		// 探测请求未返回前拒绝其他请求
+		return false
+	default:
+		return true
+	}
+}
+
+// Success 记录一次成功
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != stateClosed {
+		log.Printf("%s 已恢复，熔断器关闭", b.name)
+	}
+	b.state = stateClosed
+	b.failures = 0
+}
+
+// Failure 记录一次失败
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		if b.state != stateOpen {
+			log.Printf("%s 连续失败 %d 次，熔断 %s", b.name, b.failures, b.openTimeout)
+		}
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps 依次执行的操作：f 失败，s 成功，w 等待熔断到期，a 放行探测请求
+		steps     string
+		wantAllow bool
+	}{
+		{name: "closed", steps: "", wantAllow: true},
+		{name: "below threshold", steps: "ff", wantAllow: true},
+		{name: "success resets failures", steps: "ffsff", wantAllow: true},
+		{name: "open at threshold", steps: "fff", wantAllow: false},
+		{name: "half open after timeout", steps: "fffw", wantAllow: true},
+		{name: "probe success closes", steps: "fffwas", wantAllow: true},
+		{name: "probe failure reopens", steps: "fffwaf", wantAllow: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("test", 3, 20*time.Millisecond)
+			for _, step := range tt.steps {
+				switch step {
+				case 'f':
+					b.Failure()
+				case 's':
+					b.Success()
+				case 'w':
+					time.Sleep(30 * time.Millisecond)
+				case 'a':
+					if !b.Allow() {
+						t.Fatalf("probe request rejected")
+					}
+				}
+			}
+			if got := b.Allow(); got != tt.wantAllow {
+				t.Errorf("Allow() = %v, want %v", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenSingleProbe(t *testing.T) {
+	b := NewCircuitBreaker("test", 1, 10*time.Millisecond)
+	b.Failure()
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatalf("first request after timeout rejected")
+	}
+	if b.Allow() {
+		t.Errorf("second request allowed while probe in flight")
+	}
+}
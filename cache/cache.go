@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/keenJoe/go-url-shortener/config"
+)
+
+var (
+	// ErrCacheMiss 缓存未命中
+	ErrCacheMiss = errors.New("缓存未命中")
+	// ErrUnavailable 缓存后端不可用（熔断中）
+	ErrUnavailable = errors.New("缓存不可用")
+)
+
+// 负缓存标记值，以 ! 开头不会与合法URL冲突
+const (
+	// NotFoundMarker 短码不存在
+	NotFoundMarker = "!not_found"
+	// ExpiredMarker 链接已过期
+	ExpiredMarker = "!expired"
+	// DisabledMarker 链接已停用
+	DisabledMarker = "!disabled"
+	// DeletedMarker 链接已删除
+	DeletedMarker = "!deleted"
+)
+
+// Cache 缓存接口
+type Cache interface {
+	// Get 获取缓存，未命中时返回 ErrCacheMiss
+	Get(key string) (string, error)
+	// Set 设置缓存，expiration 为0表示不过期
+	Set(key string, value string, expiration time.Duration) error
+	// Delete 删除缓存
+	Delete(key string) error
+	// Incr 计数器加一并返回新值
+	Incr(key string) (int64, error)
+}
+
+// NewBreaker 根据配置创建Redis熔断器，Redis缓存和失效广播共用
+func NewBreaker(conf config.CacheConfig) *CircuitBreaker {
+	return NewCircuitBreaker("redis", conf.Breaker.FailureThreshold, conf.Breaker.OpenTimeout)
+}
+
+// New 根据配置创建缓存，driver 为 none 时 local 可以为空，为 redis 时 client、bus 和 breaker 不能为空
+func New(conf config.CacheConfig, local *LocalCache, client *redis.Client, bus InvalidationBus, breaker *CircuitBreaker) Cache {
+	switch conf.Driver {
+	case config.CacheNone:
+		return NopCache{}
+	case config.CacheLocal:
+		return NewLocalOnlyCache(local)
+	default:
+		return NewLayeredCache(local, NewRedisCache(client, breaker), bus, conf.LocalTTL)
+	}
+}
+
+// NewInvalidationBus 根据配置创建失效广播，只有 redis 驱动需要跨实例广播
+func NewInvalidationBus(conf config.CacheConfig, client *redis.Client, breaker *CircuitBreaker) InvalidationBus {
+	if conf.Driver != config.CacheRedis {
+		return NopInvalidationBus{}
+	}
+	return NewRedisInvalidationBus(client, breaker, conf.InvalidationChannel)
+}
+
+// SetURL 缓存URL映射
+func SetURL(c Cache, shortCode, originalURL string, expiration time.Duration) error {
+	return c.Set("url:"+shortCode, originalURL, expiration)
+}
+
+// GetURL 获取URL映射
+func GetURL(c Cache, shortCode string) (string, error) {
+	return c.Get("url:" + shortCode)
+}
+
+// DeleteURL 删除URL映射
+func DeleteURL(c Cache, shortCode string) error {
+	return c.Delete("url:" + shortCode)
+}
+
+// IncrementCounter 增加访问计数
+func IncrementCounter(c Cache, shortCode string) error {
+	_, err := c.Incr("counter:" + shortCode)
+	return err
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 默认的失效广播频道
+const defaultInvalidationChannel = "url-shortener:invalidate"
+
+// InvalidationBus 缓存失效广播，用于通知所有实例删除本地缓存中的键
+type InvalidationBus interface {
+	// Publish 广播需要失效的键
+	Publish(key string) error
+	// Subscribe 订阅其他实例广播的失效键，收到后调用 handler；
+	// 订阅建立（包括断线重连）时调用 reset，断线期间的广播可能已丢失
+	Subscribe(handler func(key string), reset func()) error
+	// Close 停止订阅
+	Close() error
+}
+
+// NopInvalidationBus 不广播，用于单实例部署
+type NopInvalidationBus struct{}
+
+// Publish 忽略广播
+func (NopInvalidationBus) Publish(key string) error {
+	return nil
+}
+
+// Subscribe 不会收到任何消息
+func (NopInvalidationBus) Subscribe(handler func(key string), reset func()) error {
+	return nil
+}
+
+// Close 无需关闭
+func (NopInvalidationBus) Close() error {
+	return nil
+}
+
+// RedisInvalidationBus 基于Redis发布订阅的失效广播
+type RedisInvalidationBus struct {
+	client     *redis.Client
+	breaker    *CircuitBreaker // 与Redis缓存共用，Redis故障时广播快速失败
+	channel    string
+	instanceID string // 消息携带发送方ID，订阅时忽略自己发出的消息
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+}
+
+// NewRedisInvalidationBus 创建基于Redis的失效广播，channel 为空时使用默认频道
+func NewRedisInvalidationBus(client *redis.Client, breaker *CircuitBreaker, channel string) *RedisInvalidationBus {
+	if channel == "" {
+		channel = defaultInvalidationChannel
+	}
+	return &RedisInvalidationBus{
+		client:     client,
+		breaker:    breaker,
+		channel:    channel,
+		instanceID: newInstanceID(),
+	}
+}
+
+// Publish 经过熔断器广播需要失效的键
+func (b *RedisInvalidationBus) Publish(key string) error {
+	if !b.breaker.Allow() {
+		return ErrUnavailable
+	}
+	if err := b.client.Publish(ctx, b.channel, b.instanceID+"|"+key).Err(); err != nil {
+		b.breaker.Failure()
+		return err
+	}
+	b.breaker.Success()
+	return nil
+}
+
+// Subscribe 订阅失效广播，断线后由客户端自动重连，每次（重新）订阅成功时调用 reset
+func (b *RedisInvalidationBus) Subscribe(handler func(key string), reset func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	b.pubsub = pubsub
+
+	go func() {
+		for received := range pubsub.ChannelWithSubscriptions(ctx, 100) {
+			var msg *redis.Message
+			switch received := received.(type) {
+			case *redis.Subscription:
+				if received.Kind == "subscribe" {
+					log.Printf("已订阅缓存失效广播，清空本地缓存")
+					reset()
+				}
+				continue
+			case *redis.Message:
+				msg = received
+			default:
+				continue
+			}
+			sender, key, ok := strings.Cut(msg.Payload, "|")
+			if !ok {
+				log.Printf("忽略格式错误的失效消息: %s", msg.Payload)
+				continue
+			}
+			if sender == b.instanceID {
+				continue
+			}
+			handler(key)
+		}
+	}()
+	return nil
+}
+
+// Close 停止订阅
+func (b *RedisInvalidationBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pubsub == nil {
+		return nil
+	}
+	return b.pubsub.Close()
+}
+
+// newInstanceID 生成随机实例ID
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRedisInvalidationBusPublishUsesBreaker(t *testing.T) {
+	// 连接不可用的地址，第一次广播失败后熔断
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	breaker := NewCircuitBreaker("test", 1, time.Minute)
+	bus := NewRedisInvalidationBus(client, breaker, "")
+
+	if err := bus.Publish("url:abc"); err == nil || errors.Is(err, ErrUnavailable) {
+		t.Fatalf("first Publish() error = %v, want connection error", err)
+	}
+	if err := bus.Publish("url:abc"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Publish() while open error = %v, want %v", err, ErrUnavailable)
+	}
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+)
+
+// 默认的短码池集合键
+const defaultKeyPoolKey = "keypool:short_code"
+
+// RedisKeyPool 基于Redis集合的预生成短码池，SPOP保证每个短码只会被一个实例领取
+type RedisKeyPool struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisKeyPool 创建Redis短码池，key 为空时使用默认键
+func NewRedisKeyPool(client *redis.Client, key string) *RedisKeyPool {
+	if key == "" {
+		key = defaultKeyPoolKey
+	}
+	return &RedisKeyPool{client: client, key: key}
+}
+
+// Add 将短码加入池中，返回实际加入的个数
+func (p *RedisKeyPool) Add(codes []string) (int, error) {
+	if len(codes) == 0 {
+		return 0, nil
+	}
+	members := make([]interface{}, len(codes))
+	for i, code := range codes {
+		members[i] = code
+	}
+	added, err := p.client.SAdd(ctx, p.key, members...).Result()
+	return int(added), err
+}
+
+// Claim 原子地弹出最多 n 个短码
+func (p *RedisKeyPool) Claim(n int) ([]string, error) {
+	return p.client.SPopN(ctx, p.key, int64(n)).Result()
+}
+
+// Available 池中剩余的短码个数
+func (p *RedisKeyPool) Available() (int64, error) {
+	return p.client.SCard(ctx, p.key).Result()
+}
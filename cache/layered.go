@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ttlGetter 读取时能同时返回剩余过期时间的缓存（Redis），用于限制回填本地缓存的过期时间
+type ttlGetter interface {
+	// GetWithTTL 获取缓存及其剩余过期时间，不过期时剩余时间不大于0
+	GetWithTTL(key string) (string, time.Duration, error)
+}
+
+// LayeredCache 两级缓存：进程内本地缓存在前，共享的远程缓存（Redis）在后。
+// 删除键时通过失效广播通知其他实例清除各自的本地缓存。
+type LayeredCache struct {
+	local    *LocalCache
+	remote   Cache
+	bus      InvalidationBus
+	localTTL time.Duration
+	// fetches 合并同一键并发的远程查询
+	fetches singleflight.Group
+}
+
+// NewLayeredCache 创建两级缓存，本地缓存的过期时间不超过 localTTL
+func NewLayeredCache(local *LocalCache, remote Cache, bus InvalidationBus, localTTL time.Duration) *LayeredCache {
+	if localTTL <= 0 {
+		localTTL = 30 * time.Minute
+	}
+	return &LayeredCache{local: local, remote: remote, bus: bus, localTTL: localTTL}
+}
+
+// Get 先查本地缓存，未命中再查远程缓存并回填本地。
+// 回填的过期时间不超过远程缓存中的剩余时间，负缓存标记和即将过期的链接不会在本地保留更久
+func (c *LayeredCache) Get(key string) (string, error) {
+	if value, found := c.local.Get(key); found {
+		return value, nil
+	}
+
+	value, err, _ := c.fetches.Do(key, func() (interface{}, error) {
+		var value string
+		var ttl time.Duration
+		var err error
+		if getter, ok := c.remote.(ttlGetter); ok {
+			value, ttl, err = getter.GetWithTTL(key)
+		} else {
+			value, err = c.remote.Get(key)
+		}
+		if err != nil {
+			return "", err
+		}
+		c.local.Set(key, value, c.capLocalTTL(ttl))
+		return value, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// Set 同时写入两级缓存，远程写入失败时本地缓存仍然生效
+func (c *LayeredCache) Set(key string, value string, expiration time.Duration) error {
+	c.local.Set(key, value, c.capLocalTTL(expiration))
+	return c.remote.Set(key, value, expiration)
+}
+
+// Delete 同时删除两级缓存，并广播让其他实例删除本地缓存。
+// 远程缓存删除失败（如熔断中）时仍然广播，避免其他实例继续使用本地缓存中的旧值
+func (c *LayeredCache) Delete(key string) error {
+	c.local.Delete(key)
+	remoteErr := c.remote.Delete(key)
+	return errors.Join(remoteErr, c.bus.Publish(key))
+}
+
+// Incr 计数器只保存在远程缓存
+func (c *LayeredCache) Incr(key string) (int64, error) {
+	return c.remote.Incr(key)
+}
+
+// capLocalTTL 本地缓存过期时间取 expiration 与 localTTL 的较小值
+func (c *LayeredCache) capLocalTTL(expiration time.Duration) time.Duration {
+	if expiration <= 0 || expiration > c.localTTL {
+		return c.localTTL
+	}
+	return expiration
+}
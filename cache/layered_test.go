@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// ttlCache 记录过期时间的远程缓存替身，实现 ttlGetter
+type ttlCache struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newTTLCache() *ttlCache {
+	return &ttlCache{values: make(map[string]string), expires: make(map[string]time.Time)}
+}
+
+func (c *ttlCache) GetWithTTL(key string) (string, time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return "", 0, ErrCacheMiss
+	}
+	if expiresAt, ok := c.expires[key]; ok {
+		ttl := time.Until(expiresAt)
+		if ttl <= 0 {
+			return "", 0, ErrCacheMiss
+		}
+		return value, ttl, nil
+	}
+	return value, 0, nil
+}
+
+func (c *ttlCache) Get(key string) (string, error) {
+	value, _, err := c.GetWithTTL(key)
+	return value, err
+}
+
+func (c *ttlCache) Set(key string, value string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	delete(c.expires, key)
+	if expiration > 0 {
+		c.expires[key] = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+func (c *ttlCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	delete(c.expires, key)
+	return nil
+}
+
+func (c *ttlCache) Incr(key string) (int64, error) {
+	return 0, nil
+}
+
+// plainCache 只实现 Cache 接口、不返回剩余过期时间的远程缓存
+type plainCache struct {
+	remote *ttlCache
+}
+
+func (c plainCache) Get(key string) (string, error) { return c.remote.Get(key) }
+func (c plainCache) Set(key string, value string, expiration time.Duration) error {
+	return c.remote.Set(key, value, expiration)
+}
+func (c plainCache) Delete(key string) error        { return c.remote.Delete(key) }
+func (c plainCache) Incr(key string) (int64, error) { return c.remote.Incr(key) }
+
+func TestLayeredCacheLocalTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteTTL time.Duration
+		localTTL  time.Duration
+		plain     bool
+		// wait 之后远程值被其他实例修改，wantAfter 为本实例读到的值
+		wait      time.Duration
+		wantAfter string
+	}{
+		{
+			name:      "negative marker expires with remote ttl",
+			remoteTTL: 30 * time.Millisecond, localTTL: time.Hour,
+			wait: 60 * time.Millisecond, wantAfter: "https://example.com/",
+		},
+		{
+			name:      "local ttl caps remote ttl",
+			remoteTTL: time.Hour, localTTL: 30 * time.Millisecond,
+			wait: 60 * time.Millisecond, wantAfter: "https://example.com/",
+		},
+		{
+			name:      "no remote expiration uses local ttl",
+			remoteTTL: 0, localTTL: 30 * time.Millisecond,
+			wait: 60 * time.Millisecond, wantAfter: "https://example.com/",
+		},
+		{
+			name:      "served locally before expiry",
+			remoteTTL: time.Hour, localTTL: time.Hour,
+			wait: 0, wantAfter: NotFoundMarker,
+		},
+		{
+			name:      "remote without ttl support uses local ttl",
+			remoteTTL: 30 * time.Millisecond, localTTL: time.Hour, plain: true,
+			wait: 60 * time.Millisecond, wantAfter: NotFoundMarker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newTTLCache()
+			var r Cache = remote
+			if tt.plain {
+				r = plainCache{remote}
+			}
+			c := NewLayeredCache(NewLocalCache(0, 0), r, nil, tt.localTTL)
+
+			remote.Set("url:abc", NotFoundMarker, tt.remoteTTL)
+			if got, err := c.Get("url:abc"); err != nil || got != NotFoundMarker {
+				t.Fatalf("Get() = %q, %v", got, err)
+			}
+			time.Sleep(tt.wait)
+			// 其他实例创建了该短码，直接写入远程缓存（未广播失效）
+			remote.Set("url:abc", "https://example.com/", 0)
+
+			if got, err := c.Get("url:abc"); err != nil || got != tt.wantAfter {
+				t.Errorf("Get() = %q, %v, want %q", got, err, tt.wantAfter)
+			}
+		})
+	}
+}
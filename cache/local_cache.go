@@ -1,60 +1,103 @@
 package cache
 
 import (
+	"container/list"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// 简单的LRU缓存实现
+// 默认最多缓存的条目数
+const defaultLocalMaxEntries = 100000
+
+// CacheItem 缓存项
 type CacheItem struct {
+	Key        string
 	Value      string
 	Expiration int64
 }
 
-type LocalCache struct {
-	items map[string]CacheItem
-	mu    sync.RWMutex
+// size 缓存项占用的字节数（按键值长度估算）
+func (item *CacheItem) size() int64 {
+	return int64(len(item.Key) + len(item.Value))
 }
 
-var localCache *LocalCache
+// LocalCacheStats 本地缓存统计
+type LocalCacheStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
 
-// InitLocalCache 初始化本地缓存
-func InitLocalCache() {
-	localCache = &LocalCache{
-		items: make(map[string]CacheItem),
+// LocalCache 有容量上限的LRU缓存，超过条目数或字节数上限时淘汰最久未使用的项
+type LocalCache struct {
+	maxEntries int
+	maxBytes   int64
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List // 队首为最近使用
+	bytes int64
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// NewLocalCache 创建本地缓存，maxEntries 为0时使用默认值，maxBytes 为0表示不限制字节数
+func NewLocalCache(maxEntries int, maxBytes int64) *LocalCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultLocalMaxEntries
+	}
+	c := &LocalCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
 	}
 
 	// 启动清理过期项的goroutine
-	go localCache.cleanupLoop()
+	go c.cleanupLoop()
+	return c
 }
 
-// Set 设置缓存
+// Set 设置缓存，duration 为0表示不过期
 func (c *LocalCache) Set(key string, value string, duration time.Duration) {
+	var expiration int64
+	if duration > 0 {
+		expiration = time.Now().Add(duration).UnixNano()
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	expiration := time.Now().Add(duration).UnixNano()
-	c.items[key] = CacheItem{
-		Value:      value,
-		Expiration: expiration,
-	}
+	c.setLocked(&CacheItem{Key: key, Value: value, Expiration: expiration})
 }
 
 // Get 获取缓存
 func (c *LocalCache) Get(key string) (string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	item, found := c.items[key]
+	elem, found := c.items[key]
 	if !found {
+		c.misses.Add(1)
 		return "", false
 	}
 
 	// 检查是否过期
+	item := elem.Value.(*CacheItem)
 	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
+		c.removeLocked(elem)
+		c.misses.Add(1)
 		return "", false
 	}
 
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
 	return item.Value, true
 }
 
@@ -63,7 +106,74 @@ func (c *LocalCache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	if elem, found := c.items[key]; found {
+		c.removeLocked(elem)
+	}
+}
+
+// Clear 删除全部缓存项
+func (c *LocalCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+}
+
+// Incr 计数器加一并返回新值，已有值无法解析为整数时从0开始
+func (c *LocalCache) Incr(key string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	if elem, found := c.items[key]; found {
+		n, _ = strconv.ParseInt(elem.Value.(*CacheItem).Value, 10, 64)
+	}
+	n++
+	c.setLocked(&CacheItem{Key: key, Value: strconv.FormatInt(n, 10)})
+	return n
+}
+
+// Stats 返回缓存统计
+func (c *LocalCache) Stats() LocalCacheStats {
+	c.mu.Lock()
+	entries, bytes := len(c.items), c.bytes
+	c.mu.Unlock()
+
+	return LocalCacheStats{
+		Entries:   entries,
+		Bytes:     bytes,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// setLocked 写入或替换缓存项并按上限淘汰，调用方需持有锁
+func (c *LocalCache) setLocked(item *CacheItem) {
+	if elem, found := c.items[item.Key]; found {
+		c.removeLocked(elem)
+	}
+
+	c.items[item.Key] = c.order.PushFront(item)
+	c.bytes += item.size()
+
+	for len(c.items) > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeLocked(oldest)
+		c.evictions.Add(1)
+	}
+}
+
+// removeLocked 移除缓存项，调用方需持有锁
+func (c *LocalCache) removeLocked(elem *list.Element) {
+	item := c.order.Remove(elem).(*CacheItem)
+	delete(c.items, item.Key)
+	c.bytes -= item.size()
 }
 
 // 定期清理过期项
@@ -82,19 +192,46 @@ func (c *LocalCache) cleanup() {
 	defer c.mu.Unlock()
 
 	now := time.Now().UnixNano()
-	for k, v := range c.items {
-		if v.Expiration > 0 && now > v.Expiration {
-			delete(c.items, k)
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if item := elem.Value.(*CacheItem); item.Expiration > 0 && now > item.Expiration {
+			c.removeLocked(elem)
 		}
+		elem = prev
 	}
 }
 
-// SetURLLocal 设置本地缓存
-func SetURLLocal(shortCode, originalURL string, duration time.Duration) {
-	localCache.Set("url:"+shortCode, originalURL, duration)
+// LocalOnlyCache 只使用进程内缓存，适用于单实例且不部署Redis的场景
+type LocalOnlyCache struct {
+	local *LocalCache
+}
+
+// NewLocalOnlyCache 创建仅本地缓存
+func NewLocalOnlyCache(local *LocalCache) *LocalOnlyCache {
+	return &LocalOnlyCache{local: local}
+}
+
+// Get 获取缓存
+func (c *LocalOnlyCache) Get(key string) (string, error) {
+	if value, found := c.local.Get(key); found {
+		return value, nil
+	}
+	return "", ErrCacheMiss
+}
+
+// Set 设置缓存
+func (c *LocalOnlyCache) Set(key string, value string, expiration time.Duration) error {
+	c.local.Set(key, value, expiration)
+	return nil
+}
+
+// Delete 删除缓存
+func (c *LocalOnlyCache) Delete(key string) error {
+	c.local.Delete(key)
+	return nil
 }
 
-// GetURLLocal 获取本地缓存
-func GetURLLocal(shortCode string) (string, bool) {
-	return localCache.Get("url:" + shortCode)
+// Incr 计数器加一
+func (c *LocalOnlyCache) Incr(key string) (int64, error) {
+	return c.local.Incr(key), nil
 }
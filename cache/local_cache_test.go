@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLocalCacheEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		// ops 依次执行：set:<key> 写入，get:<key> 读取（刷新最近使用）
+		ops           []string
+		wantKeys      []string
+		wantEvictions int64
+	}{
+		{
+			name:       "within limits",
+			maxEntries: 3,
+			ops:        []string{"set:a", "set:b", "set:c"},
+			wantKeys:   []string{"a", "b", "c"},
+		},
+		{
+			name:          "evicts least recently set",
+			maxEntries:    2,
+			ops:           []string{"set:a", "set:b", "set:c"},
+			wantKeys:      []string{"b", "c"},
+			wantEvictions: 1,
+		},
+		{
+			name:          "get refreshes recency",
+			maxEntries:    2,
+			ops:           []string{"set:a", "set:b", "get:a", "set:c"},
+			wantKeys:      []string{"a", "c"},
+			wantEvictions: 1,
+		},
+		{
+			name:          "overwrite does not evict",
+			maxEntries:    2,
+			ops:           []string{"set:a", "set:b", "set:a"},
+			wantKeys:      []string{"a", "b"},
+			wantEvictions: 0,
+		},
+		{
+			// 每项为键1字节加值1字节
+			name:          "byte limit",
+			maxEntries:    10,
+			maxBytes:      4,
+			ops:           []string{"set:a", "set:b", "set:c"},
+			wantKeys:      []string{"b", "c"},
+			wantEvictions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLocalCache(tt.maxEntries, tt.maxBytes)
+			for _, op := range tt.ops {
+				key := op[4:]
+				if op[:3] == "set" {
+					c.Set(key, "v", 0)
+				} else {
+					c.Get(key)
+				}
+			}
+
+			var keys []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, found := c.Get(key); found {
+					keys = append(keys, key)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			stats := c.Stats()
+			if stats.Evictions != tt.wantEvictions {
+				t.Errorf("Evictions = %d, want %d", stats.Evictions, tt.wantEvictions)
+			}
+			if stats.Entries != len(tt.wantKeys) || stats.Bytes != int64(2*len(tt.wantKeys)) {
+				t.Errorf("Entries, Bytes = %d, %d, want %d, %d", stats.Entries, stats.Bytes, len(tt.wantKeys), 2*len(tt.wantKeys))
+			}
+		})
+	}
+}
+
+func TestLocalCacheExpiration(t *testing.T) {
+	c := NewLocalCache(0, 0)
+	c.Set("short", "v", 10*time.Millisecond)
+	c.Set("forever", "v", 0)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := c.Get("short"); found {
+		t.Errorf("expired item still returned")
+	}
+	if _, found := c.Get("forever"); !found {
+		t.Errorf("item without expiration missing")
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit, 1 miss, 1 entry", stats)
+	}
+}
+
+func TestLocalCacheIncr(t *testing.T) {
+	c := NewLocalCache(0, 0)
+	c.Set("counter", "not a number", 0)
+	for want := int64(1); want <= 3; want++ {
+		if got := c.Incr("counter"); got != want {
+			t.Errorf("Incr() = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestLocalCacheClear(t *testing.T) {
+	c := NewLocalCache(0, 0)
+	c.Set("a", "v", 0)
+	c.Set("b", "v", 0)
+	c.Clear()
+
+	if _, found := c.Get("a"); found {
+		t.Errorf("item still present after Clear")
+	}
+	if stats := c.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() = %+v, want empty", stats)
+	}
+	c.Set("c", "v", 0)
+	if _, found := c.Get("c"); !found {
+		t.Errorf("Set after Clear not stored")
+	}
+}
@@ -0,0 +1,26 @@
+package cache
+
+import "time"
+
+// NopCache 不缓存任何数据，用于只依赖数据库运行的场景
+type NopCache struct{}
+
+// Get 始终未命中
+func (NopCache) Get(key string) (string, error) {
+	return "", ErrCacheMiss
+}
+
+// Set 忽略写入
+func (NopCache) Set(key string, value string, expiration time.Duration) error {
+	return nil
+}
+
+// Delete 忽略删除
+func (NopCache) Delete(key string) error {
+	return nil
+}
+
+// Incr 不计数
+func (NopCache) Incr(key string) (int64, error) {
+	return 0, nil
+}
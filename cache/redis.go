@@ -2,18 +2,18 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/keenJoe/go-url-shortener/config"
 )
 
-var RedisClient *redis.Client
 var ctx = context.Background()
 
-// InitRedis 初始化Redis连接
-func InitRedis(conf *config.Config) error {
-	RedisClient = redis.NewClient(&redis.Options{
+// NewRedisClient 创建Redis连接并测试连通性，连接失败时仍返回客户端，由调用方决定是否降级
+func NewRedisClient(conf *config.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
 		Addr:         conf.Redis.Addr,
 		Password:     conf.Redis.Password,
 		DB:           conf.Redis.DB,
@@ -22,30 +22,89 @@ func InitRedis(conf *config.Config) error {
 		MaxConnAge:   time.Hour,           // 连接最大年龄
 	})
 
-	return nil
+	return client, client.Ping(ctx).Err()
+}
+
+// RedisCache 基于Redis的缓存，Redis故障时由熔断器快速失败
+type RedisCache struct {
+	client  *redis.Client
+	breaker *CircuitBreaker
 }
 
-// SetURL 缓存URL映射
-func SetURL(shortCode, originalURL string, expiration time.Duration) error {
-	return RedisClient.Set(ctx, "url:"+shortCode, originalURL, expiration).Err()
+// NewRedisCache 创建Redis缓存
+func NewRedisCache(client *redis.Client, breaker *CircuitBreaker) *RedisCache {
+	return &RedisCache{client: client, breaker: breaker}
 }
 
-// GetURL 获取URL映射
-func GetURL(shortCode string) (string, error) {
-	return RedisClient.Get(ctx, "url:"+shortCode).Result()
+// Get 获取缓存
+func (c *RedisCache) Get(key string) (string, error) {
+	var value string
+	err := c.do(func() error {
+		var err error
+		value, err = c.client.Get(ctx, key).Result()
+		return err
+	})
+	return value, err
 }
 
-// DeleteURL 删除URL映射
-func DeleteURL(shortCode string) error {
-	return RedisClient.Del(ctx, "url:"+shortCode).Err()
+// GetWithTTL 在同一事务中获取缓存和剩余过期时间，不过期时剩余时间不大于0
+func (c *RedisCache) GetWithTTL(key string) (string, time.Duration, error) {
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	err := c.do(func() error {
+		_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			get = pipe.Get(ctx, key)
+			pttl = pipe.PTTL(ctx, key)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return get.Val(), pttl.Val(), nil
 }
 
-// IncrementCounter 增加访问计数
-func IncrementCounter(shortCode string) error {
-	return RedisClient.Incr(ctx, "counter:"+shortCode).Err()
+// Set 设置缓存
+func (c *RedisCache) Set(key string, value string, expiration time.Duration) error {
+	return c.do(func() error {
+		return c.client.Set(ctx, key, value, expiration).Err()
+	})
 }
 
-// GetCounter 获取访问计数
-func GetCounter(shortCode string) (int64, error) {
-	return RedisClient.Get(ctx, "counter:"+shortCode).Int64()
+// Delete 删除缓存
+func (c *RedisCache) Delete(key string) error {
+	return c.do(func() error {
+		return c.client.Del(ctx, key).Err()
+	})
+}
+
+// Incr 计数器加一
+func (c *RedisCache) Incr(key string) (int64, error) {
+	var value int64
+	err := c.do(func() error {
+		var err error
+		value, err = c.client.Incr(ctx, key).Result()
+		return err
+	})
+	return value, err
+}
+
+// do 经过熔断器执行Redis命令，redis.Nil 视为未命中而非故障
+func (c *RedisCache) do(fn func() error) error {
+	if !c.breaker.Allow() {
+		return ErrUnavailable
+	}
+
+	err := fn()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.breaker.Failure()
+		return err
+	}
+
+	c.breaker.Success()
+	if err != nil {
+		return ErrCacheMiss
+	}
+	return nil
 }
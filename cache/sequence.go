@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+)
+
+// 默认的短码序列键
+const defaultSequenceKey = "sequence:short_code"
+
+// RedisSequence 基于Redis INCRBY的序列，所有实例共享同一计数器
+type RedisSequence struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisSequence 创建Redis序列，key 为空时使用默认键
+func NewRedisSequence(client *redis.Client, key string) *RedisSequence {
+	if key == "" {
+		key = defaultSequenceKey
+	}
+	return &RedisSequence{client: client, key: key}
+}
+
+// NextBlock 原子地预留 size 个连续值，返回第一个值，序列从1开始。
+// Redis数据丢失会导致序列回退，需开启持久化
+func (s *RedisSequence) NextBlock(size uint64) (uint64, error) {
+	end, err := s.client.IncrBy(ctx, s.key, int64(size)).Result()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(end) - size + 1, nil
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// 默认的布隆过滤器快照键
+const defaultSnapshotKey = "bloom:snapshot:short_code"
+
+// RedisSnapshotStore 将布隆过滤器快照保存在Redis键中，便于无持久磁盘的实例共享
+type RedisSnapshotStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisSnapshotStore 创建Redis快照存储，key 为空时使用默认键
+func NewRedisSnapshotStore(client *redis.Client, key string) *RedisSnapshotStore {
+	if key == "" {
+		key = defaultSnapshotKey
+	}
+	return &RedisSnapshotStore{client: client, key: key}
+}
+
+// Save 保存快照
+func (s *RedisSnapshotStore) Save(data []byte) error {
+	return s.client.Set(ctx, s.key, data, 0).Err()
+}
+
+// Load 读取快照
+func (s *RedisSnapshotStore) Load() ([]byte, error) {
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, utils.ErrSnapshotNotFound
+	}
+	return data, err
+}
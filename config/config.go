@@ -5,25 +5,35 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config 配置结构体
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Bloom     BloomConfig     `yaml:"bloom"`
+	ShortCode ShortCodeConfig `yaml:"shortcode"`
+	URL       URLConfig       `yaml:"url"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port int    `yaml:"port"`
-	Mode string `yaml:"mode"`
+	Port         int    `yaml:"port"`
+	Mode         string `yaml:"mode"`
+	DisabledPage string `yaml:"disabled_page"` // 访问已停用链接时返回的HTML页面文件，为空时返回JSON
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver       string `yaml:"driver"`  // mysql、postgres 或 sqlite，默认 mysql
+	Path         string `yaml:"path"`    // sqlite 数据库文件路径
+	SSLMode      string `yaml:"sslmode"` // postgres 连接的 sslmode，默认 disable
 	Host         string `yaml:"host"`
 	Port         int    `yaml:"port"`
 	Username     string `yaml:"username"`
@@ -31,6 +41,9 @@ type DatabaseConfig struct {
 	DBName       string `yaml:"name"`
 	MaxIdleConns int    `yaml:"max_idle_conns"`
 	MaxOpenConns int    `yaml:"max_open_conns"`
+	AutoMigrate  bool   `yaml:"auto_migrate"` // 启动时自动执行数据库迁移
+	// CleanupInterval 定期删除过期链接的间隔，0 表示不清理
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
 }
 
 // RedisConfig Redis配置
@@ -41,6 +54,146 @@ type RedisConfig struct {
 	PoolSize int    `yaml:"pool_size"`
 }
 
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Driver              string        `yaml:"driver"`               // redis、local 或 none，默认 redis
+	LocalTTL            time.Duration `yaml:"local_ttl"`            // 本地缓存最长保留时间，默认 30m
+	LocalMaxEntries     int           `yaml:"local_max_entries"`    // 本地缓存最大条目数，默认 100000
+	LocalMaxBytes       int64         `yaml:"local_max_bytes"`      // 本地缓存最大字节数（按键值长度估算），0 表示不限制
+	NegativeTTL         time.Duration `yaml:"negative_ttl"`         // 不存在或已过期短码的负缓存时间，默认 1m
+	InvalidationChannel string        `yaml:"invalidation_channel"` // 跨实例广播缓存失效的Redis频道
+	Breaker             BreakerConfig `yaml:"breaker"`
+}
+
+// BreakerConfig Redis熔断器配置
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"` // 连续失败多少次后熔断，默认 5
+	OpenTimeout      time.Duration `yaml:"open_timeout"`      // 熔断持续时间，默认 10s
+}
+
+// BloomConfig 布隆过滤器配置
+type BloomConfig struct {
+	ExpectedItems     uint64              `yaml:"expected_items"`      // 预期元素数，默认 10000000
+	FalsePositiveRate float64             `yaml:"false_positive_rate"` // 目标假阳性率，默认 0.01
+	WarmupBatchSize   int                 `yaml:"warmup_batch_size"`   // 启动预热时每批从存储读取的短码数，默认 1000
+	Backend           string              `yaml:"backend"`             // local（进程内）、counting（进程内，支持删除）或 redis（Redis位图，跨实例共享），默认 local
+	RedisKey          string              `yaml:"redis_key"`           // redis 后端的位图键前缀，默认 bloom:short_code
+	Snapshot          BloomSnapshotConfig `yaml:"snapshot"`            // 仅 local 后端使用
+}
+
+// BloomSnapshotConfig 布隆过滤器快照配置
+type BloomSnapshotConfig struct {
+	Driver   string        `yaml:"driver"`    // file、redis 或 none，默认 none
+	Path     string        `yaml:"path"`      // file 驱动的快照文件路径
+	RedisKey string        `yaml:"redis_key"` // redis 驱动的快照键，默认 bloom:snapshot:short_code
+	Interval time.Duration `yaml:"interval"`  // 定期保存间隔，默认 10m
+}
+
+// ShortCodeConfig 短码生成配置
+type ShortCodeConfig struct {
+	Generator string `yaml:"generator"` // random、readable、hash、sequence 或 pool，默认 random
+	Length    int    `yaml:"length"`    // 生成短码的长度，默认 7
+	Alphabet  string `yaml:"alphabet"`  // 生成短码的字符集，默认为大小写字母和数字
+	// 短码大小写不敏感：只用小写字符生成，访问、缓存和过滤器忽略大小写，存储层按小写短码保证唯一
+	CaseInsensitive bool            `yaml:"case_insensitive"`
+	Alias           AliasConfig     `yaml:"alias"`
+	Hash            HashConfig      `yaml:"hash"`
+	Blocklist       BlocklistConfig `yaml:"blocklist"`
+	Sequence        SequenceConfig  `yaml:"sequence"`
+	Pool            KeyPoolConfig   `yaml:"pool"`
+}
+
+// URLConfig 原始URL规范化配置，规范形式相同的URL复用同一短码。修改后已有记录保留原来的规范形式
+type URLConfig struct {
+	StripParams       []string `yaml:"strip_params"`        // 去掉的查询参数（忽略大小写），以 * 结尾时按前缀匹配，如 utm_*
+	KeepTrailingSlash bool     `yaml:"keep_trailing_slash"` // 保留路径末尾的 /，默认去掉
+	StripFragment     bool     `yaml:"strip_fragment"`      // 去掉片段（# 之后的部分），默认保留
+}
+
+// HashConfig 确定性哈希短码配置，修改后同一URL会得到不同的短码
+type HashConfig struct {
+	Secret string `yaml:"secret"` // HMAC密钥，可通过环境变量 SHORTCODE_HASH_SECRET 覆盖
+	Salt   string `yaml:"salt"`   // 混入哈希的盐值
+}
+
+// AliasConfig 自定义别名规则
+type AliasConfig struct {
+	MinLength int      `yaml:"min_length"` // 最小长度，默认 3
+	MaxLength int      `yaml:"max_length"` // 最大长度，默认 32
+	Symbols   string   `yaml:"symbols"`    // 除字母数字外允许出现在中间的符号，只能是 - 和 _
+	Reserved  []string `yaml:"reserved"`   // 额外的保留词，已注册的路由前缀总是保留
+}
+
+// BlocklistConfig 屏蔽词配置，同时作用于自定义别名和生成短码
+type BlocklistConfig struct {
+	Words []string `yaml:"words"` // 屏蔽词
+	File  string   `yaml:"file"`  // 屏蔽词文件，每行一个词
+}
+
+// SequenceConfig 自增ID短码配置
+type SequenceConfig struct {
+	Backend        string `yaml:"backend"`         // database 或 redis，默认 database
+	BlockSize      uint64 `yaml:"block_size"`      // 每次预留的ID个数，默认 100
+	RedisKey       string `yaml:"redis_key"`       // redis 后端的计数器键，默认 sequence:short_code
+	Obfuscate      bool   `yaml:"obfuscate"`       // 是否对ID做可逆置换，使短码不可预测
+	ObfuscationKey uint64 `yaml:"obfuscation_key"` // 置换密钥，修改后新生成的短码可能与已有短码冲突
+}
+
+// KeyPoolConfig 预生成短码池配置
+type KeyPoolConfig struct {
+	Backend        string        `yaml:"backend"`         // database 或 redis，默认 database
+	RedisKey       string        `yaml:"redis_key"`       // redis 后端的集合键，默认 keypool:short_code
+	BatchSize      int           `yaml:"batch_size"`      // 每个实例每次领取的短码数，默认 100
+	Size           int64         `yaml:"size"`            // 池的目标容量，剩余不足一半时补充，默认 100000
+	RefillInterval time.Duration `yaml:"refill_interval"` // 检查并补充池的间隔，默认 1m
+}
+
+// 支持的短码生成方式
+const (
+	GeneratorRandom   = "random"
+	GeneratorReadable = "readable"
+	GeneratorHash     = "hash"
+	GeneratorSequence = "sequence"
+	GeneratorPool     = "pool"
+)
+
+// MaxShortCodeLength 短码（包括自定义别名）的最大长度，与 urls.short_code 列宽一致
+const MaxShortCodeLength = 64
+
+// 序列和短码池支持的存储后端
+const (
+	BackendDatabase = "database"
+	BackendRedis    = "redis"
+)
+
+// 支持的快照存储
+const (
+	SnapshotNone  = "none"
+	SnapshotFile  = "file"
+	SnapshotRedis = "redis"
+)
+
+// 支持的布隆过滤器后端
+const (
+	BloomLocal    = "local"
+	BloomCounting = "counting"
+	BloomRedis    = "redis"
+)
+
+// 支持的缓存驱动
+const (
+	CacheRedis = "redis"
+	CacheLocal = "local"
+	CacheNone  = "none"
+)
+
 var globalConfig *Config
 
 // LoadConfig 加载配置文件
@@ -88,6 +241,64 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
+	// 未指定驱动时默认使用MySQL
+	if config.Database.Driver == "" {
+		config.Database.Driver = DriverMySQL
+	}
+
+	// 未指定缓存驱动时默认使用Redis
+	if config.Cache.Driver == "" {
+		config.Cache.Driver = CacheRedis
+	}
+
+	// 未指定布隆过滤器后端时使用进程内过滤器
+	if config.Bloom.Backend == "" {
+		config.Bloom.Backend = BloomLocal
+	}
+
+	// 未指定快照存储时不使用快照
+	if config.Bloom.Snapshot.Driver == "" {
+		config.Bloom.Snapshot.Driver = SnapshotNone
+	}
+
+	// 未指定短码生成方式时使用随机短码
+	if config.ShortCode.Generator == "" {
+		config.ShortCode.Generator = GeneratorRandom
+	}
+	// 哈希密钥优先从环境变量读取，避免写在配置文件中
+	if secret := os.Getenv("SHORTCODE_HASH_SECRET"); secret != "" {
+		config.ShortCode.Hash.Secret = secret
+	}
+	if config.ShortCode.Length == 0 {
+		config.ShortCode.Length = 7
+	}
+	if config.ShortCode.Alias.MinLength == 0 {
+		config.ShortCode.Alias.MinLength = 3
+	}
+	if config.ShortCode.Alias.MaxLength == 0 {
+		config.ShortCode.Alias.MaxLength = 32
+	}
+	if config.ShortCode.Sequence.Backend == "" {
+		config.ShortCode.Sequence.Backend = BackendDatabase
+	}
+	if config.ShortCode.Pool.Backend == "" {
+		config.ShortCode.Pool.Backend = BackendDatabase
+	}
+	if config.ShortCode.CaseInsensitive {
+		// 大小写不敏感模式下过滤器保存小写短码，与区分大小写时的数据分开存放，避免恢复出不一致的过滤器
+		if config.Bloom.RedisKey == "" {
+			config.Bloom.RedisKey = "bloom:short_code"
+		}
+		if config.Bloom.Snapshot.RedisKey == "" {
+			config.Bloom.Snapshot.RedisKey = "bloom:snapshot:short_code"
+		}
+		config.Bloom.RedisKey += ":ci"
+		config.Bloom.Snapshot.RedisKey += ":ci"
+		if config.Bloom.Snapshot.Path != "" {
+			config.Bloom.Snapshot.Path += ".ci"
+		}
+	}
+
 	// 验证必要的配置项
 	if err := validateConfig(config); err != nil {
 		return nil, fmt.Errorf("配置验证失败: %v", err)
@@ -97,9 +308,11 @@ func LoadConfig() (*Config, error) {
 	globalConfig = config
 
 	// 打印关键配置信息（注意隐藏敏感信息）
-	log.Printf("配置加载成功: Server.Port=%d, Database.Host=%s, Redis.Addr=%s",
+	log.Printf("配置加载成功: Server.Port=%d, Database.Driver=%s, Database.Host=%s, Cache.Driver=%s, Redis.Addr=%s",
 		config.Server.Port,
+		config.Database.Driver,
 		config.Database.Host,
+		config.Cache.Driver,
 		config.Redis.Addr)
 
 	return config, nil
@@ -110,11 +323,99 @@ func validateConfig(config *Config) error {
 	if config.Server.Port == 0 {
 		return fmt.Errorf("server.port 未配置")
 	}
-	if config.Database.Host == "" {
-		return fmt.Errorf("database.host 未配置")
+
+	switch config.Database.Driver {
+	case "", DriverMySQL, DriverPostgres:
+		if config.Database.Host == "" {
+			return fmt.Errorf("database.host 未配置")
+		}
+		if config.Database.DBName == "" {
+			return fmt.Errorf("database.name 未配置")
+		}
+	case DriverSQLite:
+		if config.Database.Path == "" {
+			return fmt.Errorf("database.path 未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的 database.driver: %s", config.Database.Driver)
 	}
-	if config.Database.DBName == "" {
-		return fmt.Errorf("database.name 未配置")
+
+	switch config.Cache.Driver {
+	case CacheRedis:
+		if config.Redis.Addr == "" {
+			return fmt.Errorf("redis.addr 未配置")
+		}
+	case CacheLocal, CacheNone:
+	default:
+		return fmt.Errorf("不支持的 cache.driver: %s", config.Cache.Driver)
+	}
+
+	switch config.Bloom.Backend {
+	case BloomLocal, BloomCounting:
+	case BloomRedis:
+		if config.Redis.Addr == "" {
+			return fmt.Errorf("redis.addr 未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的 bloom.backend: %s", config.Bloom.Backend)
+	}
+
+	switch config.Bloom.Snapshot.Driver {
+	case SnapshotFile:
+		if config.Bloom.Snapshot.Path == "" {
+			return fmt.Errorf("bloom.snapshot.path 未配置")
+		}
+	case SnapshotRedis:
+		if config.Redis.Addr == "" {
+			return fmt.Errorf("redis.addr 未配置")
+		}
+	case SnapshotNone:
+	default:
+		return fmt.Errorf("不支持的 bloom.snapshot.driver: %s", config.Bloom.Snapshot.Driver)
+	}
+
+	if config.ShortCode.Length < 1 || config.ShortCode.Length > MaxShortCodeLength {
+		return fmt.Errorf("shortcode.length 必须在 1 到 %d 之间", MaxShortCodeLength)
+	}
+	alias := config.ShortCode.Alias
+	if alias.MinLength < 1 || alias.MaxLength > MaxShortCodeLength || alias.MinLength > alias.MaxLength {
+		return fmt.Errorf("shortcode.alias 长度范围不合法: %d-%d", alias.MinLength, alias.MaxLength)
+	}
+	if strings.Trim(alias.Symbols, "-_") != "" {
+		return fmt.Errorf("shortcode.alias.symbols 只能包含 - 和 _")
+	}
+
+	switch config.ShortCode.Generator {
+	case GeneratorRandom, GeneratorReadable:
+	case GeneratorHash:
+		// 没有密钥时任何人都能根据URL算出短码
+		if config.ShortCode.Hash.Secret == "" {
+			return fmt.Errorf("shortcode.hash.secret 未配置")
+		}
+	case GeneratorSequence:
+		if err := validateBackend("shortcode.sequence.backend", config.ShortCode.Sequence.Backend, config); err != nil {
+			return err
+		}
+	case GeneratorPool:
+		if err := validateBackend("shortcode.pool.backend", config.ShortCode.Pool.Backend, config); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("不支持的 shortcode.generator: %s", config.ShortCode.Generator)
+	}
+	return nil
+}
+
+// validateBackend 验证序列或短码池的存储后端
+func validateBackend(name, backend string, config *Config) error {
+	switch backend {
+	case BackendDatabase:
+	case BackendRedis:
+		if config.Redis.Addr == "" {
+			return fmt.Errorf("redis.addr 未配置")
+		}
+	default:
+		return fmt.Errorf("不支持的 %s: %s", name, backend)
 	}
 	return nil
 }
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validTestConfig 返回可以通过验证的最小配置
+func validTestConfig() *Config {
+	config := &Config{}
+	config.Server.Port = 8080
+	config.Database.Driver = DriverSQLite
+	config.Database.Path = "test.db"
+	config.Cache.Driver = CacheLocal
+	config.Bloom.Backend = BloomLocal
+	config.Bloom.Snapshot.Driver = SnapshotNone
+	config.ShortCode.Generator = GeneratorRandom
+	config.ShortCode.Length = 7
+	config.ShortCode.Alias.MinLength = 3
+	config.ShortCode.Alias.MaxLength = 32
+	return config
+}
+
+func TestValidateConfigHashSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator string
+		secret    string
+		wantErr   string
+	}{
+		{name: "hash with secret", generator: GeneratorHash, secret: "secret"},
+		{name: "hash without secret", generator: GeneratorHash, wantErr: "shortcode.hash.secret"},
+		{name: "random without secret", generator: GeneratorRandom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validTestConfig()
+			config.ShortCode.Generator = tt.generator
+			config.ShortCode.Hash.Secret = tt.secret
+
+			err := validateConfig(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateConfig() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateConfig() error = %v, want error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,11 +3,14 @@ package database
 import (
 	"fmt"
 	"log"
-	"strings"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/keenJoe/go-url-shortener/config"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -16,29 +19,19 @@ var DB *gorm.DB
 
 // InitDB 初始化数据库连接
 func InitDB(conf *config.Config) error {
-	// 打印配置信息（隐藏密码）
-	log.Printf("正在连接数据库: host=%s, port=%d, user=%s, dbname=%s, password=%s",
-		conf.Database.Host,
-		conf.Database.Port,
-		conf.Database.Username,
-		conf.Database.DBName,
-		conf.Database.Password)
-
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		conf.Database.Username,
-		conf.Database.Password,
-		conf.Database.Host,
-		conf.Database.Port,
-		conf.Database.DBName)
+	dialector, err := openDialector(conf.Database)
+	if err != nil {
+		return err
+	}
 
 	// 尝试连接数据库
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// 将唯一约束冲突等驱动错误转换为 gorm.ErrDuplicatedKey
+		TranslateError: true,
 	})
 	if err != nil {
-		return fmt.Errorf("连接测试失败: %v (DSN: %s)",
-			err,
-			strings.Replace(dsn, conf.Database.Password, "****", 1))
+		return fmt.Errorf("连接测试失败: %v (%s)", err, describe(conf.Database))
 	}
 
 	// 获取底层的sql.DB对象
@@ -53,10 +46,69 @@ func InitDB(conf *config.Config) error {
 	}
 
 	// 设置连接池参数
-	sqlDB.SetMaxIdleConns(conf.Database.MaxIdleConns) // 空闲连接数
-	sqlDB.SetMaxOpenConns(conf.Database.MaxOpenConns) // 最大连接数
-	sqlDB.SetConnMaxLifetime(time.Hour)               // 连接最大生命周期
+	if conf.Database.Driver == config.DriverSQLite {
+		// SQLite同一时间只允许一个写入者，单连接可避免 database is locked
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(conf.Database.MaxIdleConns) // 空闲连接数
+		sqlDB.SetMaxOpenConns(conf.Database.MaxOpenConns) // 最大连接数
+	}
+	sqlDB.SetConnMaxLifetime(time.Hour) // 连接最大生命周期
 
 	DB = db
 	return nil
 }
+
+// openDialector 根据配置的驱动创建GORM方言
+func openDialector(conf config.DatabaseConfig) (gorm.Dialector, error) {
+	// 打印配置信息（隐藏密码）
+	log.Printf("正在连接数据库: %s", describe(conf))
+
+	switch conf.Driver {
+	case config.DriverSQLite:
+		// 确保数据库文件所在目录存在
+		if dir := filepath.Dir(conf.Path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("创建SQLite目录失败: %v", err)
+			}
+		}
+		dsn := conf.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+		return sqlite.Open(dsn), nil
+	case config.DriverPostgres:
+		sslMode := conf.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			conf.Host,
+			conf.Port,
+			conf.Username,
+			conf.Password,
+			conf.DBName,
+			sslMode)
+		return postgres.Open(dsn), nil
+	default:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			conf.Username,
+			conf.Password,
+			conf.Host,
+			conf.Port,
+			conf.DBName)
+		return mysql.Open(dsn), nil
+	}
+}
+
+// describe 返回不含密码的连接描述，用于日志和错误信息
+func describe(conf config.DatabaseConfig) string {
+	switch conf.Driver {
+	case config.DriverSQLite:
+		return fmt.Sprintf("driver=sqlite, path=%s", conf.Path)
+	default:
+		return fmt.Sprintf("driver=%s, host=%s, port=%d, user=%s, dbname=%s, password=****",
+			conf.Driver,
+			conf.Host,
+			conf.Port,
+			conf.Username,
+			conf.DBName)
+	}
+}
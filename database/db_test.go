@@ -0,0 +1,98 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keenJoe/go-url-shortener/config"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+)
+
+func TestInitDBSQLite(t *testing.T) {
+	// 数据库文件所在目录不存在时自动创建
+	path := filepath.Join(t.TempDir(), "data", "nested", "app.db")
+	conf := &config.Config{Database: config.DatabaseConfig{Driver: config.DriverSQLite, Path: path}}
+	if err := InitDB(conf); err != nil {
+		t.Fatalf("InitDB() error = %v", err)
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		t.Fatalf("DB() error = %v", err)
+	}
+	defer sqlDB.Close()
+
+	if name := DB.Dialector.Name(); name != "sqlite" {
+		t.Errorf("Dialector = %s, want sqlite", name)
+	}
+	if got := sqlDB.Stats().MaxOpenConnections; got != 1 {
+		t.Errorf("MaxOpenConnections = %d, want 1", got)
+	}
+	var journalMode string
+	if err := DB.Raw("PRAGMA journal_mode").Scan(&journalMode).Error; err != nil || journalMode != "wal" {
+		t.Errorf("journal_mode = %q, %v, want wal", journalMode, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database file not created: %v", err)
+	}
+}
+
+func TestOpenDialector(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     config.DatabaseConfig
+		wantName string
+		wantDSN  string
+	}{
+		{
+			name:     "mysql",
+			conf:     config.DatabaseConfig{Driver: config.DriverMySQL, Host: "db", Port: 3306, Username: "u", Password: "p", DBName: "urls"},
+			wantName: "mysql",
+			wantDSN:  "u:p@tcp(db:3306)/urls?charset=utf8mb4&parseTime=True&loc=Local",
+		},
+		{
+			name:     "postgres default sslmode",
+			conf:     config.DatabaseConfig{Driver: config.DriverPostgres, Host: "db", Port: 5432, Username: "u", Password: "p", DBName: "urls"},
+			wantName: "postgres",
+			wantDSN:  "host=db port=5432 user=u password=p dbname=urls sslmode=disable",
+		},
+		{
+			name: "postgres sslmode",
+			conf: config.DatabaseConfig{Driver: config.DriverPostgres, Host: "db", Port: 5432, Username: "u", Password: "p", DBName: "urls",
+				SSLMode: "require"},
+			wantName: "postgres",
+			wantDSN:  "host=db port=5432 user=u password=p dbname=urls sslmode=require",
+		},
+		{
+			name:     "sqlite",
+			conf:     config.DatabaseConfig{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "app.db")},
+			wantName: "sqlite",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialector, err := openDialector(tt.conf)
+			if err != nil {
+				t.Fatalf("openDialector() error = %v", err)
+			}
+			if name := dialector.Name(); name != tt.wantName {
+				t.Errorf("Name() = %s, want %s", name, tt.wantName)
+			}
+			var dsn string
+			switch d := dialector.(type) {
+			case *mysql.Dialector:
+				dsn = d.DSN
+			case *postgres.Dialector:
+				dsn = d.DSN
+			}
+			if dsn != tt.wantDSN {
+				t.Errorf("DSN = %q, want %q", dsn, tt.wantDSN)
+			}
+			if tt.conf.Password != "" && strings.Contains(describe(tt.conf), "password="+tt.conf.Password) {
+				t.Errorf("describe() leaks password: %s", describe(tt.conf))
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,11 +11,20 @@ import (
 
 // CreateURLRequest 创建URL请求
 type CreateURLRequest struct {
-	OriginalURL string `json:"original_url" binding:"required,url"`
-	CustomAlias string `json:"custom_alias"`
-	ExpiresIn   int64  `json:"expires_in"` // 过期时间（秒）
+	OriginalURL string   `json:"original_url" binding:"required,url"`
+	CustomAlias string   `json:"custom_alias"`
+	ExpiresIn   int64    `json:"expires_in"` // 过期时间（秒）
+	ForceNew    bool     `json:"force_new"`  // 总是创建新链接，不复用已有链接
+	Title       string   `json:"title"`
+	Tags        []string `json:"tags"`
 }
 
+// OwnerHeader 标识创建者的请求头，只在同一创建者的链接之间去重
+const OwnerHeader = "X-Owner-ID"
+
+// maxOwnerIDLength 创建者标识的最大长度，与 owner_id 列一致
+const maxOwnerIDLength = 64
+
 // CreateURLResponse 创建URL响应
 type CreateURLResponse struct {
 	ShortCode   string `json:"short_code"`
@@ -24,40 +34,69 @@ type CreateURLResponse struct {
 }
 
 // CreateURL 创建短链接
-func CreateURL(c *gin.Context) {
+func (h *Handler) CreateURL(c *gin.Context) {
 	var req CreateURLRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
 		return
 	}
 
+	ownerID := c.GetHeader(OwnerHeader)
+	if len(ownerID) > maxOwnerIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的创建者标识"})
+		return
+	}
+
 	// 设置过期时间
 	var expiration time.Duration
 	if req.ExpiresIn > 0 {
 		expiration = time.Duration(req.ExpiresIn) * time.Second
 	}
 
-	// 创建短链接
-	shortCode, err := services.CreateShortURL(req.OriginalURL, req.CustomAlias, expiration)
+	// 创建短链接，可能复用同一创建者已有的链接
+	url, err := h.urlService.CreateShortURL(req.OriginalURL, services.CreateOptions{
+		OwnerID:     ownerID,
+		CustomAlias: req.CustomAlias,
+		Expiration:  expiration,
+		ForceNew:    req.ForceNew,
+		Title:       req.Title,
+		Tags:        req.Tags,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(createErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	// 构建短链接URL
 	baseURL := "http://" + c.Request.Host
-	shortURL := baseURL + "/" + shortCode
+	shortURL := baseURL + "/" + url.ShortCode
 
 	// 设置过期时间
 	var expiresAt string
 	if req.ExpiresIn > 0 {
-		expiresAt = time.Now().Add(expiration).Format(time.RFC3339)
+		expiresAt = url.ExpiresAt.Format(time.RFC3339)
 	}
 
 	c.JSON(http.StatusOK, CreateURLResponse{
-		ShortCode:   shortCode,
+		ShortCode:   url.ShortCode,
 		ShortURL:    shortURL,
-		OriginalURL: req.OriginalURL,
+		OriginalURL: url.OriginalURL,
 		ExpiresAt:   expiresAt,
 	})
 }
+
+// createErrorStatus 根据创建短链接的错误返回对应的HTTP状态码
+func createErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlias),
+		errors.Is(err, services.ErrAliasReserved),
+		errors.Is(err, services.ErrAliasBlocked),
+		errors.Is(err, services.ErrInvalidTitle),
+		errors.Is(err, services.ErrInvalidTags):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrAliasTaken):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keenJoe/go-url-shortener/cache"
+	"github.com/keenJoe/go-url-shortener/repository"
+	"github.com/keenJoe/go-url-shortener/services"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// newTestEngine 基于内存存储创建只注册了处理器路由的引擎，保留词为 api，屏蔽词为 bad
+func newTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	urls := repository.NewMemoryURLRepository()
+	filter := services.NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), nil, 0, false)
+	if err := filter.WarmUp(); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	urlService := services.NewURLService(urls, cache.NewLocalOnlyCache(cache.NewLocalCache(0, 0)), filter,
+		utils.NewBloomFilter(1000, 0.01), services.URLServiceOptions{
+			AliasRules: utils.DefaultAliasRules(),
+			Policy:     utils.NewCodePolicy([]string{"api"}, utils.NewWordBlocklist([]string{"bad"})),
+		})
+	handler := NewHandler(urlService, nil, filter, NewMetrics(), nil)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/api/shorten", handler.CreateURL)
+	engine.GET("/api/links", handler.ListLinks)
+	engine.GET("/api/links/:shortCode", handler.GetLink)
+	return engine
+}
+
+// doRequest 发送请求，ownerID 非空时携带创建者请求头
+func doRequest(engine *gin.Engine, method, path, body, ownerID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ownerID != "" {
+		req.Header.Set(OwnerHeader, ownerID)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateURLAliasErrors(t *testing.T) {
+	engine := newTestEngine(t)
+	if w := doRequest(engine, http.MethodPost, "/api/shorten",
+		`{"original_url":"https://example.com/taken","custom_alias":"taken"}`, ""); w.Code != http.StatusOK {
+		t.Fatalf("create alias status = %d, body %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name      string
+		alias     string
+		wantCode  int
+		wantError error
+	}{
+		{name: "valid", alias: "my-link", wantCode: http.StatusOK},
+		{name: "invalid char", alias: "my.link", wantCode: http.StatusBadRequest, wantError: services.ErrInvalidAlias},
+		{name: "leading hyphen", alias: "-link", wantCode: http.StatusBadRequest, wantError: services.ErrInvalidAlias},
+		{name: "too short", alias: "ab", wantCode: http.StatusBadRequest, wantError: services.ErrInvalidAlias},
+		{name: "reserved", alias: "API", wantCode: http.StatusBadRequest, wantError: services.ErrAliasReserved},
+		{name: "blocked", alias: "so-BAD-link", wantCode: http.StatusBadRequest, wantError: services.ErrAliasBlocked},
+		{name: "taken", alias: "taken", wantCode: http.StatusConflict, wantError: services.ErrAliasTaken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(engine, http.MethodPost, "/api/shorten",
+				`{"original_url":"https://example.com/`+tt.name+`","custom_alias":"`+tt.alias+`"}`, "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantError == nil {
+				return
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != tt.wantError.Error() {
+				t.Errorf("error = %q (%v), want %q", body.Error, err, tt.wantError.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/keenJoe/go-url-shortener/services"
+)
+
+// Handler HTTP处理器，持有各业务服务
+type Handler struct {
+	urlService    *services.URLService
+	statsService  *services.StatsService
+	filterService *services.FilterService
+	metrics       *Metrics
+	// disabledPage 访问已停用链接时返回的HTML页面，为空时返回JSON
+	disabledPage []byte
+}
+
+// NewHandler 创建HTTP处理器
+func NewHandler(urlService *services.URLService, statsService *services.StatsService, filterService *services.FilterService,
+	metrics *Metrics, disabledPage []byte) *Handler {
+	return &Handler{
+		urlService:    urlService,
+		statsService:  statsService,
+		filterService: filterService,
+		metrics:       metrics,
+		disabledPage:  disabledPage,
+	}
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz 存活探针
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz 就绪探针，布隆过滤器预热完成前返回503
+func (h *Handler) Readyz(c *gin.Context) {
+	if !h.filterService.Ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "warming_up"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
+}
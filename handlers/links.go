@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/services"
+)
+
+// LinkResponse 链接详情
+type LinkResponse struct {
+	ShortCode    string     `json:"short_code"`
+	ShortURL     string     `json:"short_url"`
+	OriginalURL  string     `json:"original_url"`
+	Title        string     `json:"title,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	CustomAlias  bool       `json:"custom_alias"`
+	OwnerID      string     `json:"owner_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // 不过期的链接不返回
+	AccessCount  int64      `json:"access_count"`
+	LastAccessAt *time.Time `json:"last_access_at,omitempty"`
+	Version      int64      `json:"version"`
+	Status       string     `json:"status"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UpdateLinkRequest 修改链接请求，未提供的字段保持不变
+type UpdateLinkRequest struct {
+	OriginalURL *string   `json:"original_url" binding:"omitempty,url"`
+	CustomAlias *string   `json:"custom_alias"`
+	ExpiresIn   *int64    `json:"expires_in" binding:"omitempty,min=0"` // 从现在起的有效期（秒），0 表示不过期
+	Title       *string   `json:"title"`
+	Tags        *[]string `json:"tags"`    // 替换全部标签
+	Version     int64     `json:"version"` // 期望的当前版本，也可通过 If-Match 请求头提供
+}
+
+// ListLinksResponse 链接列表
+type ListLinksResponse struct {
+	Links      []LinkResponse `json:"links"`
+	NextCursor string         `json:"next_cursor,omitempty"` // 下一页的游标，没有下一页时为空
+}
+
+// ListLinks 分页查询请求者（X-Owner-ID）创建的链接，未携带时返回 401。
+// 筛选参数：owner_id（只能是请求者自己）、tag、domain、status（逗号分隔）、created_after、created_before、
+// expires_after、expires_before（RFC3339）、q（在URL和标题中查找）；
+// 排序和分页参数：sort（created_at、access_count、last_access_at）、order（asc、desc）、cursor、limit
+func (h *Handler) ListLinks(c *gin.Context) {
+	opts := services.ListOptions{
+		Tag:    c.Query("tag"),
+		Domain: c.Query("domain"),
+		Search: c.Query("q"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	ownerID, ok := requestOwner(c)
+	if !ok {
+		return
+	}
+	if owner, ok := c.GetQuery("owner_id"); ok && owner != ownerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能查询自己创建的链接"})
+		return
+	}
+	opts.OwnerID = &ownerID
+	if status := c.Query("status"); status != "" {
+		opts.Statuses = strings.Split(status, ",")
+	}
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		opts.Asc = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidQuery.Error()})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidQuery.Error()})
+			return
+		}
+		opts.Limit = n
+	}
+	for param, target := range map[string]*time.Time{
+		"created_after":  &opts.CreatedAfter,
+		"created_before": &opts.CreatedBefore,
+		"expires_after":  &opts.ExpiresAfter,
+		"expires_before": &opts.ExpiresBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时间参数: " + param})
+			return
+		}
+		*target = t
+	}
+
+	urls, next, err := h.urlService.ListShortURLs(opts)
+	if err != nil {
+		c.JSON(linkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	links := make([]LinkResponse, len(urls))
+	for i := range urls {
+		links[i] = newLinkResponse(c, &urls[i])
+	}
+	c.JSON(http.StatusOK, ListLinksResponse{Links: links, NextCursor: next})
+}
+
+// GetLink 获取请求者（X-Owner-ID）创建的链接详情，ETag 为当前版本。未携带请求头时返回 401，不是创建者时返回 403
+func (h *Handler) GetLink(c *gin.Context) {
+	ownerID, ok := requestOwner(c)
+	if !ok {
+		return
+	}
+	url, err := h.urlService.GetOwnedShortURL(c.Param("shortCode"), ownerID)
+	if err != nil {
+		c.JSON(linkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", versionETag(url.Version))
+	c.JSON(http.StatusOK, newLinkResponse(c, url))
+}
+
+// UpdateLink 修改链接的目标URL、过期时间或短码，只有创建者（X-Owner-ID 一致）可以修改。
+// 请求携带 If-Match 或 version 时，版本与当前不一致返回412
+func (h *Handler) UpdateLink(c *gin.Context) {
+	var req UpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数"})
+		return
+	}
+	if req.OriginalURL == nil && req.CustomAlias == nil && req.ExpiresIn == nil && req.Title == nil && req.Tags == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有需要修改的字段"})
+		return
+	}
+
+	opts := services.UpdateOptions{
+		OriginalURL: req.OriginalURL,
+		CustomAlias: req.CustomAlias,
+		Title:       req.Title,
+		Tags:        req.Tags,
+		Version:     req.Version,
+		OwnerID:     c.GetHeader(OwnerHeader),
+	}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && ifMatch != "*" {
+		version, ok := parseVersionETag(ifMatch)
+		if !ok {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": services.ErrVersionConflict.Error()})
+			return
+		}
+		opts.Version = version
+	}
+	if req.ExpiresIn != nil {
+		expiration := time.Duration(*req.ExpiresIn) * time.Second
+		opts.Expiration = &expiration
+	}
+
+	url, err := h.urlService.UpdateShortURL(c.Param("shortCode"), opts)
+	if err != nil {
+		c.JSON(linkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", versionETag(url.Version))
+	c.JSON(http.StatusOK, newLinkResponse(c, url))
+}
+
+// DeleteLink 软删除链接，之后访问返回410
+func (h *Handler) DeleteLink(c *gin.Context) {
+	if _, err := h.urlService.DeleteShortURL(c.Param("shortCode"), c.GetHeader(OwnerHeader)); err != nil {
+		c.JSON(linkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DisableLink 停用链接
+func (h *Handler) DisableLink(c *gin.Context) {
+	h.respondLink(c, h.urlService.DisableShortURL)
+}
+
+// EnableLink 重新启用已停用的链接
+func (h *Handler) EnableLink(c *gin.Context) {
+	h.respondLink(c, h.urlService.EnableShortURL)
+}
+
+// respondLink 以请求者的身份对路径中的短码执行操作并返回修改后的链接详情
+func (h *Handler) respondLink(c *gin.Context, action func(shortCode, ownerID string) (*models.URL, error)) {
+	url, err := action(c.Param("shortCode"), c.GetHeader(OwnerHeader))
+	if err != nil {
+		c.JSON(linkErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", versionETag(url.Version))
+	c.JSON(http.StatusOK, newLinkResponse(c, url))
+}
+
+// requestOwner 返回请求头中的创建者标识，未携带时返回 401、过长时返回 400
+func requestOwner(c *gin.Context) (string, bool) {
+	ownerID := c.GetHeader(OwnerHeader)
+	if ownerID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少创建者标识请求头 " + OwnerHeader})
+		return "", false
+	}
+	if len(ownerID) > maxOwnerIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的创建者标识"})
+		return "", false
+	}
+	return ownerID, true
+}
+
+// newLinkResponse 构建链接详情
+func newLinkResponse(c *gin.Context, url *models.URL) LinkResponse {
+	resp := LinkResponse{
+		ShortCode:   url.ShortCode,
+		ShortURL:    "http://" + c.Request.Host + "/" + url.ShortCode,
+		OriginalURL: url.OriginalURL,
+		Title:       url.Title,
+		Tags:        url.Tags,
+		CustomAlias: url.CustomAlias,
+		OwnerID:     url.OwnerID,
+		CreatedAt:   url.CreatedAt,
+		UpdatedAt:   url.UpdatedAt,
+		AccessCount: url.AccessCount,
+		Version:     url.Version,
+		Status:      url.Status,
+		DeletedAt:   url.DeletedAt,
+	}
+	if url.ExpiresIn > 0 {
+		resp.ExpiresAt = &url.ExpiresAt
+	}
+	if !url.LastAccessAt.IsZero() {
+		resp.LastAccessAt = &url.LastAccessAt
+	}
+	return resp
+}
+
+// versionETag 返回版本对应的ETag
+func versionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseVersionETag 从 If-Match 的ETag中解析版本，忽略弱校验前缀
+func parseVersionETag(etag string) (int64, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	version, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
+	return version, err == nil && version > 0
+}
+
+// linkErrorStatus 根据查询或修改链接的错误返回对应的HTTP状态码
+func linkErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDeleted):
+		return http.StatusGone
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidQuery), errors.Is(err, services.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	default:
+		return createErrorStatus(err)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestLinksRequireOwner(t *testing.T) {
+	engine := newTestEngine(t)
+	for _, link := range []struct{ alias, ownerID string }{
+		{alias: "alice-link", ownerID: "alice"},
+		{alias: "bob-link", ownerID: "bob"},
+		{alias: "anon-link"},
+	} {
+		w := doRequest(engine, http.MethodPost, "/api/shorten",
+			`{"original_url":"https://example.com/`+link.alias+`","custom_alias":"`+link.alias+`"}`, link.ownerID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("create %s status = %d, body %s", link.alias, w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		ownerID  string
+		wantCode int
+	}{
+		{name: "list without owner", path: "/api/links", wantCode: http.StatusUnauthorized},
+		{name: "list other owner", path: "/api/links?owner_id=bob", ownerID: "alice", wantCode: http.StatusForbidden},
+		{name: "list own", path: "/api/links", ownerID: "alice", wantCode: http.StatusOK},
+		{name: "get without owner", path: "/api/links/alice-link", wantCode: http.StatusUnauthorized},
+		{name: "get anonymous link without owner", path: "/api/links/anon-link", wantCode: http.StatusUnauthorized},
+		{name: "get other owner's link", path: "/api/links/alice-link", ownerID: "bob", wantCode: http.StatusForbidden},
+		{name: "get anonymous link", path: "/api/links/anon-link", ownerID: "alice", wantCode: http.StatusForbidden},
+		{name: "get own link", path: "/api/links/alice-link", ownerID: "alice", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(engine, http.MethodGet, tt.path, "", tt.ownerID)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+
+	// 列表只包含请求者自己的链接
+	w := doRequest(engine, http.MethodGet, "/api/links", "", "alice")
+	var list ListLinksResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Links) != 1 || list.Links[0].ShortCode != "alice-link" || list.Links[0].OwnerID != "alice" {
+		t.Errorf("ListLinks() = %+v, want only alice-link", list.Links)
+	}
+}
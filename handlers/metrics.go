@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 运行指标注册表，各组件注册返回当前指标的函数
+type Metrics struct {
+	mu      sync.RWMutex
+	sources map[string]func() interface{}
+}
+
+// NewMetrics 创建指标注册表
+func NewMetrics() *Metrics {
+	return &Metrics{sources: make(map[string]func() interface{})}
+}
+
+// Register 注册指标来源
+func (m *Metrics) Register(name string, source func() interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sources[name] = source
+}
+
+// GetMetrics 返回所有已注册的运行指标
+func (h *Handler) GetMetrics(c *gin.Context) {
+	h.metrics.mu.RLock()
+	defer h.metrics.mu.RUnlock()
+
+	result := make(gin.H, len(h.metrics.sources))
+	for name, source := range h.metrics.sources {
+		result[name] = source()
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,38 +1,40 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/keenJoe/go-url-shortener/database"
-	"github.com/keenJoe/go-url-shortener/models"
 	"github.com/keenJoe/go-url-shortener/services"
 )
 
 // RedirectURL 重定向到原始URL
-func RedirectURL(c *gin.Context) {
+func (h *Handler) RedirectURL(c *gin.Context) {
 	shortCode := c.Param("shortCode")
 
 	// 获取原始URL
-	originalURL, err := services.GetOriginalURL(shortCode)
-	if err != nil {
+	originalURL, err := h.urlService.GetOriginalURL(shortCode)
+	switch {
+	case errors.Is(err, services.ErrDeleted):
+		c.JSON(http.StatusGone, gin.H{"error": "链接已删除"})
+		return
+	case errors.Is(err, services.ErrDisabled):
+		if len(h.disabledPage) > 0 {
+			c.Data(http.StatusForbidden, "text/html; charset=utf-8", h.disabledPage)
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "链接已停用"})
+		}
+		return
+	case err != nil:
 		c.JSON(http.StatusNotFound, gin.H{"error": "链接不存在或已过期"})
 		return
 	}
 
-	// 异步记录访问统计
-	go func() {
-		var url models.URL
-		database.DB.Where("short_code = ?", shortCode).First(&url)
-
-		services.RecordURLAccess(
-			url.ID,
-			c.ClientIP(),
-			c.Request.UserAgent(),
-			c.Request.Referer(),
-		)
-	}()
+	// 异步记录访问统计（gin.Context会被复用，需先取出请求信息）
+	ip, userAgent, referer := c.ClientIP(), c.Request.UserAgent(), c.Request.Referer()
+	go h.statsService.RecordURLAccess(shortCode, ip, userAgent, referer)
 
-	// 重定向到原始URL
-	c.Redirect(http.StatusMovedPermanently, originalURL)
+	// 重定向到原始URL。链接可以修改、停用或删除，且每次访问都需要计入统计，因此使用临时重定向并禁止缓存
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, originalURL)
 }
@@ -4,14 +4,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/keenJoe/go-url-shortener/services"
 )
 
 // GetURLStats 获取URL访问统计
-func GetURLStats(c *gin.Context) {
+func (h *Handler) GetURLStats(c *gin.Context) {
 	shortCode := c.Param("shortCode")
 
-	stats, err := services.GetURLStats(shortCode)
+	stats, err := h.statsService.GetURLStats(shortCode)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "链接不存在"})
 		return
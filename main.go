@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/keenJoe/go-url-shortener/cache"
 	"github.com/keenJoe/go-url-shortener/config"
 	"github.com/keenJoe/go-url-shortener/database"
+	"github.com/keenJoe/go-url-shortener/handlers"
 	"github.com/keenJoe/go-url-shortener/middleware"
+	"github.com/keenJoe/go-url-shortener/migrations"
+	"github.com/keenJoe/go-url-shortener/repository"
 	"github.com/keenJoe/go-url-shortener/routers"
+	"github.com/keenJoe/go-url-shortener/services"
+	"github.com/keenJoe/go-url-shortener/utils"
 )
 
 func main() {
@@ -27,9 +40,190 @@ func main() {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 
-	// 初始化Redis
-	if err := cache.InitRedis(conf); err != nil {
-		log.Fatalf("初始化Redis失败: %v", err)
+	// 子命令: migrate up | migrate down [n] | migrate status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	}
+
+	// 启动时执行数据库迁移
+	if conf.Database.AutoMigrate {
+		if _, err := migrations.NewMigrator(database.DB).Up(); err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+	}
+
+	// 初始化缓存，Redis不可用时由熔断器降级为直接查询数据库
+	var redisClient *redis.Client
+	useSequenceRedis := conf.ShortCode.Generator == config.GeneratorSequence &&
+		conf.ShortCode.Sequence.Backend == config.BackendRedis
+	useKeyPoolRedis := conf.ShortCode.Generator == config.GeneratorPool &&
+		conf.ShortCode.Pool.Backend == config.BackendRedis
+	if conf.Cache.Driver == config.CacheRedis || conf.Bloom.Backend == config.BloomRedis ||
+		conf.Bloom.Snapshot.Driver == config.SnapshotRedis || useSequenceRedis || useKeyPoolRedis {
+		redisClient, err = cache.NewRedisClient(conf)
+		if err != nil {
+			log.Printf("连接Redis失败，将降级运行: %v", err)
+		}
+	}
+	metrics := handlers.NewMetrics()
+	var localCache *cache.LocalCache
+	if conf.Cache.Driver != config.CacheNone {
+		localCache = cache.NewLocalCache(conf.Cache.LocalMaxEntries, conf.Cache.LocalMaxBytes)
+		metrics.Register("local_cache", func() interface{} { return localCache.Stats() })
+	}
+	// 订阅其他实例的失效广播，删除本地缓存中的对应键
+	redisBreaker := cache.NewBreaker(conf.Cache)
+	invalidationBus := cache.NewInvalidationBus(conf.Cache, redisClient, redisBreaker)
+	if localCache != nil {
+		if err := invalidationBus.Subscribe(localCache.Delete, localCache.Clear); err != nil {
+			log.Fatalf("订阅缓存失效广播失败: %v", err)
+		}
+	}
+	defer invalidationBus.Close()
+	urlCache := cache.New(conf.Cache, localCache, redisClient, invalidationBus, redisBreaker)
+
+	// 初始化布隆过滤器
+	utils.InitBloomFilters(conf.Bloom.ExpectedItems, conf.Bloom.FalsePositiveRate)
+	metrics.Register("original_url_filter", func() interface{} { return utils.OriginalURLFilter.Stats() })
+	var shortCodeFilter utils.Filter = utils.ShortCodeFilter
+	switch conf.Bloom.Backend {
+	case config.BloomCounting:
+		// 计数布隆过滤器支持删除过期短码，内存占用是普通过滤器的4倍
+		shortCodeFilter = utils.NewCountingBloomFilter(conf.Bloom.ExpectedItems, conf.Bloom.FalsePositiveRate)
+	case config.BloomRedis:
+		shortCodeFilter = cache.NewRedisBloomFilter(redisClient, conf.Bloom.RedisKey,
+			conf.Bloom.ExpectedItems, conf.Bloom.FalsePositiveRate)
+	}
+	var snapshotStore utils.SnapshotStore
+	switch conf.Bloom.Snapshot.Driver {
+	case config.SnapshotFile:
+		snapshotStore = utils.NewFileSnapshotStore(conf.Bloom.Snapshot.Path)
+	case config.SnapshotRedis:
+		snapshotStore = cache.NewRedisSnapshotStore(redisClient, conf.Bloom.Snapshot.RedisKey)
+	}
+
+	// 组装存储与服务
+	urlRepo := repository.NewGormURLRepository(database.DB)
+	caseInsensitive := conf.ShortCode.CaseInsensitive
+	if caseInsensitive {
+		// 回填小写短码列，已有仅大小写不同的短码时唯一约束失败，需要先人工处理
+		if err := urlRepo.EnableCaseInsensitive(); err != nil {
+			log.Fatalf("开启短码大小写不敏感模式失败，请检查是否存在仅大小写不同的短码: %v", err)
+		}
+	}
+	// 按当前规范化规则为已有记录回填 canonical_url
+	normalizer := &utils.URLNormalizer{
+		StripParams:       conf.URL.StripParams,
+		KeepTrailingSlash: conf.URL.KeepTrailingSlash,
+		StripFragment:     conf.URL.StripFragment,
+	}
+	if filled, err := urlRepo.BackfillCanonicalURLs(normalizer.Normalize, 500); err != nil {
+		log.Fatalf("回填规范化URL失败: %v", err)
+	} else if filled > 0 {
+		log.Printf("已为 %d 条记录回填规范化URL", filled)
+	}
+	statsRepo := repository.NewGormStatsRepository(database.DB)
+	filterService := services.NewFilterService(urlRepo, shortCodeFilter, snapshotStore, conf.Bloom.WarmupBatchSize,
+		caseInsensitive)
+	metrics.Register("short_code_filter", func() interface{} { return filterService.Stats() })
+	// 短码生成策略：生成短码和自定义别名各自使用独立的校验规则
+	chars := conf.ShortCode.Alphabet
+	if chars == "" {
+		chars = utils.Base62Chars
+	}
+	alphabet, err := utils.NewAlphabet(chars)
+	if err == nil && caseInsensitive {
+		// 大小写不敏感模式只使用小写字符生成短码
+		alphabet, err = alphabet.Lower()
+	}
+	if err != nil {
+		log.Fatalf("shortcode.alphabet 配置错误: %v", err)
+	}
+	length := conf.ShortCode.Length
+	var generator utils.ShortCodeGenerator
+	var keyPool *services.KeyPool
+	switch conf.ShortCode.Generator {
+	case config.GeneratorReadable:
+		if generator, err = utils.NewReadableGenerator(alphabet, length); err != nil {
+			log.Fatalf("shortcode.alphabet 配置错误: %v", err)
+		}
+	case config.GeneratorHash:
+		generator = utils.NewHashGenerator(alphabet, length, conf.ShortCode.Hash.Secret, conf.ShortCode.Hash.Salt)
+	case config.GeneratorSequence:
+		// 按号段预留ID，多实例之间不会重复
+		sequenceRepo := repository.NewGormSequenceRepository(database.DB)
+		source := func(size uint64) (uint64, error) {
+			return sequenceRepo.NextBlock("short_code", size)
+		}
+		if useSequenceRedis {
+			source = cache.NewRedisSequence(redisClient, conf.ShortCode.Sequence.RedisKey).NextBlock
+		}
+		generator = utils.NewSequenceGenerator(
+			utils.NewBlockAllocator(source, conf.ShortCode.Sequence.BlockSize), alphabet, length,
+			conf.ShortCode.Sequence.Obfuscate, conf.ShortCode.Sequence.ObfuscationKey)
+	case config.GeneratorPool:
+		// 预生成短码池，各实例原子地按批领取
+		var poolStore repository.KeyPoolRepository = repository.NewGormKeyPoolRepository(database.DB)
+		if useKeyPoolRedis {
+			poolStore = cache.NewRedisKeyPool(redisClient, conf.ShortCode.Pool.RedisKey)
+		}
+		keyPool = services.NewKeyPool(urlRepo, poolStore, utils.NewRandomGenerator(alphabet, length),
+			conf.ShortCode.Pool.BatchSize, conf.ShortCode.Pool.Size)
+		generator = keyPool
+	default:
+		generator = utils.NewRandomGenerator(alphabet, length)
+	}
+	// 保留词与屏蔽词，路由前缀在注册路由后追加
+	blockWords := conf.ShortCode.Blocklist.Words
+	if conf.ShortCode.Blocklist.File != "" {
+		fileWords, err := utils.ReadWordList(conf.ShortCode.Blocklist.File)
+		if err != nil {
+			log.Fatalf("加载屏蔽词失败: %v", err)
+		}
+		blockWords = append(blockWords, fileWords...)
+	}
+	codePolicy := utils.NewCodePolicy(conf.ShortCode.Alias.Reserved, utils.NewWordBlocklist(blockWords))
+	urlService := services.NewURLService(urlRepo, urlCache, filterService, utils.OriginalURLFilter, services.URLServiceOptions{
+		NegativeTTL: conf.Cache.NegativeTTL,
+		Generator:   generator,
+		CodeRules:   utils.CodeRules{Alphabet: alphabet, MinLength: length, MaxLength: length},
+		AliasRules: utils.CodeRules{
+			Alphabet:   utils.MustAlphabet(utils.Base62Chars),
+			MinLength:  conf.ShortCode.Alias.MinLength,
+			MaxLength:  conf.ShortCode.Alias.MaxLength,
+			InnerChars: conf.ShortCode.Alias.Symbols,
+		},
+		Policy:          codePolicy,
+		Normalizer:      normalizer,
+		CaseInsensitive: caseInsensitive,
+	})
+	statsService := services.NewStatsService(urlRepo, statsRepo)
+
+	// 后台预热布隆过滤器（优先从快照恢复），完成前 /readyz 返回503，失败时定期重试
+	stop := make(chan struct{})
+	go func() {
+		for {
+			err := filterService.WarmUp()
+			if err == nil {
+				break
+			}
+			log.Printf("预热布隆过滤器失败，10秒后重试: %v", err)
+			time.Sleep(10 * time.Second)
+		}
+		filterService.RunSnapshotLoop(conf.Bloom.Snapshot.Interval, stop)
+	}()
+
+	// 后台补充预生成短码池
+	if keyPool != nil {
+		go keyPool.RunRefillLoop(conf.ShortCode.Pool.RefillInterval, stop)
+	}
+
+	// 定期删除过期链接，并从支持删除的过滤器中移除对应短码
+	if conf.Database.CleanupInterval > 0 {
+		go urlService.RunCleanupLoop(conf.Database.CleanupInterval, stop)
 	}
 
 	// 创建gin实例
@@ -43,12 +237,43 @@ func main() {
 	api := router.Group("/api")
 	api.Use(middleware.RateLimit(100, 200))
 	// 注册路由
-	routerGroup := routers.InitRouter()
+	var disabledPage []byte
+	if conf.Server.DisabledPage != "" {
+		if disabledPage, err = os.ReadFile(conf.Server.DisabledPage); err != nil {
+			log.Fatalf("读取停用链接页面失败: %v", err)
+		}
+	}
+	routerGroup := routers.InitRouter(handlers.NewHandler(urlService, statsService, filterService, metrics, disabledPage))
 	routerGroup.Register(router)
+	// 路由前缀不能用作短码，否则会被对应路由遮蔽
+	codePolicy.Reserve(routers.ReservedPrefixes(router)...)
 
 	// 启动服务
-	serverAddr := fmt.Sprintf(":%d", conf.Server.Port)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("启动服务失败: %v", err)
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", conf.Server.Port),
+		Handler: router,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("启动服务失败: %v", err)
+		}
+	}()
+
+	// 等待退出信号，优雅关闭
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("正在关闭服务...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("关闭HTTP服务失败: %v", err)
+	}
+
+	// 停止后台任务并保存最终的布隆过滤器快照
+	close(stop)
+	if err := filterService.SaveSnapshot(); err != nil {
+		log.Printf("保存布隆过滤器快照失败: %v", err)
 	}
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/keenJoe/go-url-shortener/database"
+	"github.com/keenJoe/go-url-shortener/migrations"
+)
+
+// runMigrate 执行 migrate 子命令: migrate up | migrate down [n] | migrate status
+func runMigrate(args []string) error {
+	migrator := migrations.NewMigrator(database.DB)
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		count, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已执行 %d 个迁移\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("无效的回滚步数: %s", args[1])
+			}
+			steps = n
+		}
+		count, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已回滚 %d 个迁移\n", count)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("未知的 migrate 命令: %s（可用: up, down [n], status）", action)
+	}
+	return nil
+}
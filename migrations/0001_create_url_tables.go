@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 迁移使用当时的表结构快照，避免模型后续变化影响已发布的迁移
+type urlV1 struct {
+	ID           uint   `gorm:"primaryKey"`
+	OriginalURL  string `gorm:"size:2048;not null"`
+	ShortCode    string `gorm:"size:10;not null;uniqueIndex:idx_urls_short_code"`
+	CustomAlias  bool   `gorm:"default:false"`
+	CreatedAt    time.Time
+	ExpiresAt    time.Time `gorm:"index:idx_urls_expires_at"`
+	AccessCount  int64     `gorm:"default:0"`
+	LastAccessAt time.Time
+}
+
+func (urlV1) TableName() string { return "urls" }
+
+type urlStatsV1 struct {
+	ID        uint      `gorm:"primaryKey"`
+	URLID     uint      `gorm:"index:idx_url_stats_url_access,priority:1"`
+	AccessIP  string    `gorm:"size:50"`
+	UserAgent string    `gorm:"size:512"`
+	Referer   string    `gorm:"size:512"`
+	AccessAt  time.Time `gorm:"index:idx_url_stats_url_access,priority:2"`
+}
+
+func (urlStatsV1) TableName() string { return "url_stats" }
+
+// createURLTables 创建 urls 和 url_stats 表；表已存在（手工建表）时只补齐索引
+var createURLTables = Migration{
+	Version: 1,
+	Name:    "create_url_tables",
+	Up: func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&urlV1{}, &urlStatsV1{}} {
+			if tx.Migrator().HasTable(model) {
+				continue
+			}
+			if err := tx.Migrator().CreateTable(model); err != nil {
+				return err
+			}
+		}
+
+		for _, index := range []struct {
+			model interface{}
+			name  string
+		}{
+			{&urlV1{}, "idx_urls_short_code"},
+			{&urlV1{}, "idx_urls_expires_at"},
+			{&urlStatsV1{}, "idx_url_stats_url_access"},
+		} {
+			if err := createIndexIfMissing(tx, index.model, index.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&urlStatsV1{}, &urlV1{})
+	},
+}
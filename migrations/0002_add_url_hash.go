@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"github.com/keenJoe/go-url-shortener/utils"
+	"gorm.io/gorm"
+)
+
+// original_url 最长2048字符，无法直接建索引，改为对其哈希建索引用于去重查询
+type urlV2 struct {
+	ID          uint   `gorm:"primaryKey"`
+	OriginalURL string `gorm:"size:2048;not null"`
+	URLHash     string `gorm:"size:64;index:idx_urls_url_hash"`
+}
+
+func (urlV2) TableName() string { return "urls" }
+
+// addURLHash 为 urls 增加 url_hash 列、回填已有数据并建立索引
+var addURLHash = Migration{
+	Version: 2,
+	Name:    "add_url_hash",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&urlV2{}, "URLHash") {
+			if err := tx.Migrator().AddColumn(&urlV2{}, "URLHash"); err != nil {
+				return err
+			}
+		}
+
+		// 分批回填已有记录的哈希
+		var batch []urlV2
+		err := tx.Where("url_hash IS NULL OR url_hash = ''").
+			FindInBatches(&batch, 500, func(batchTx *gorm.DB, _ int) error {
+				for _, url := range batch {
+					if err := tx.Model(&urlV2{}).Where("id = ?", url.ID).
+						Update("url_hash", utils.HashURL(url.OriginalURL)).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return createIndexIfMissing(tx, &urlV2{}, "idx_urls_url_hash")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dropIndexIfExists(tx, &urlV2{}, "idx_urls_url_hash"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&urlV2{}, "URLHash")
+	},
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// 版本3时 sequences 表的结构快照
+type sequenceV3 struct {
+	Name      string `gorm:"primaryKey;size:64"`
+	NextValue uint64 `gorm:"not null"`
+}
+
+func (sequenceV3) TableName() string { return "sequences" }
+
+// createSequences 创建号段分配使用的 sequences 表
+var createSequences = Migration{
+	Version: 3,
+	Name:    "create_sequences",
+	Up: func(tx *gorm.DB) error {
+		if tx.Migrator().HasTable(&sequenceV3{}) {
+			return nil
+		}
+		return tx.Migrator().CreateTable(&sequenceV3{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&sequenceV3{})
+	},
+}
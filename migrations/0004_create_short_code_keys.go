@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 版本4时 short_code_keys 表的结构快照
+type shortCodeKeyV4 struct {
+	ID         uint    `gorm:"primaryKey"`
+	Code       string  `gorm:"size:10;not null;uniqueIndex:idx_short_code_keys_code"`
+	ClaimToken *string `gorm:"size:32;index:idx_short_code_keys_claim_token"`
+	ClaimedAt  *time.Time
+}
+
+func (shortCodeKeyV4) TableName() string { return "short_code_keys" }
+
+// createShortCodeKeys 创建预生成短码池使用的 short_code_keys 表
+var createShortCodeKeys = Migration{
+	Version: 4,
+	Name:    "create_short_code_keys",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasTable(&shortCodeKeyV4{}) {
+			if err := tx.Migrator().CreateTable(&shortCodeKeyV4{}); err != nil {
+				return err
+			}
+		}
+		for _, name := range []string{"idx_short_code_keys_code", "idx_short_code_keys_claim_token"} {
+			if err := createIndexIfMissing(tx, &shortCodeKeyV4{}, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&shortCodeKeyV4{})
+	},
+}
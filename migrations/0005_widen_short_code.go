@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// 短码长度和自定义别名长度改为可配置，短码列加宽到64
+type urlV5 struct {
+	ID        uint   `gorm:"primaryKey"`
+	ShortCode string `gorm:"size:64;not null;uniqueIndex:idx_urls_short_code"`
+}
+
+func (urlV5) TableName() string { return "urls" }
+
+type shortCodeKeyV5 struct {
+	ID   uint   `gorm:"primaryKey"`
+	Code string `gorm:"size:64;not null;uniqueIndex:idx_short_code_keys_code"`
+}
+
+func (shortCodeKeyV5) TableName() string { return "short_code_keys" }
+
+// 回滚时恢复的列宽
+type urlV4 struct {
+	ID        uint   `gorm:"primaryKey"`
+	ShortCode string `gorm:"size:10;not null;uniqueIndex:idx_urls_short_code"`
+}
+
+func (urlV4) TableName() string { return "urls" }
+
+type shortCodeKeyV4Code struct {
+	ID   uint   `gorm:"primaryKey"`
+	Code string `gorm:"size:10;not null;uniqueIndex:idx_short_code_keys_code"`
+}
+
+func (shortCodeKeyV4Code) TableName() string { return "short_code_keys" }
+
+// widenShortCode 将 urls.short_code 和 short_code_keys.code 加宽到64个字符。
+// SQLite 的 TEXT 不限制长度，且修改列需要重建表（会丢失索引），因此跳过
+var widenShortCode = Migration{
+	Version: 5,
+	Name:    "widen_short_code",
+	Up: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		if err := tx.Migrator().AlterColumn(&urlV5{}, "ShortCode"); err != nil {
+			return err
+		}
+		return tx.Migrator().AlterColumn(&shortCodeKeyV5{}, "Code")
+	},
+	Down: func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "sqlite" {
+			return nil
+		}
+		if err := tx.Migrator().AlterColumn(&shortCodeKeyV4Code{}, "Code"); err != nil {
+			return err
+		}
+		return tx.Migrator().AlterColumn(&urlV4{}, "ShortCode")
+	},
+}
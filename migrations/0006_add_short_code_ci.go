@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// 大小写不敏感模式下保存小写短码，唯一索引允许多个NULL，区分大小写模式下不受约束
+type urlV6 struct {
+	ID          uint    `gorm:"primaryKey"`
+	ShortCodeCI *string `gorm:"size:64;uniqueIndex:idx_urls_short_code_ci"`
+}
+
+func (urlV6) TableName() string { return "urls" }
+
+// addShortCodeCI 为 urls 增加 short_code_ci 列及唯一索引，数据由开启大小写不敏感模式时回填
+var addShortCodeCI = Migration{
+	Version: 6,
+	Name:    "add_short_code_ci",
+	Up: func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(&urlV6{}, "ShortCodeCI") {
+			if err := tx.Migrator().AddColumn(&urlV6{}, "ShortCodeCI"); err != nil {
+				return err
+			}
+		}
+		return createIndexIfMissing(tx, &urlV6{}, "idx_urls_short_code_ci")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dropIndexIfExists(tx, &urlV6{}, "idx_urls_short_code_ci"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&urlV6{}, "ShortCodeCI")
+	},
+}
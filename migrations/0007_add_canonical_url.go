@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// 规范化后的URL及其哈希，去重时按 canonical_hash 索引查询
+type urlV7 struct {
+	ID            uint   `gorm:"primaryKey"`
+	CanonicalURL  string `gorm:"size:2048"`
+	CanonicalHash string `gorm:"size:64;index:idx_urls_canonical_hash"`
+}
+
+func (urlV7) TableName() string { return "urls" }
+
+// addCanonicalURL 为 urls 增加 canonical_url、canonical_hash 列及索引。
+// 规范化规则来自配置，已有数据由服务启动时按当前规则回填
+var addCanonicalURL = Migration{
+	Version: 7,
+	Name:    "add_canonical_url",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"CanonicalURL", "CanonicalHash"} {
+			if tx.Migrator().HasColumn(&urlV7{}, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(&urlV7{}, field); err != nil {
+				return err
+			}
+		}
+		return createIndexIfMissing(tx, &urlV7{}, "idx_urls_canonical_hash")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dropIndexIfExists(tx, &urlV7{}, "idx_urls_canonical_hash"); err != nil {
+			return err
+		}
+		for _, field := range []string{"CanonicalHash", "CanonicalURL"} {
+			if err := tx.Migrator().DropColumn(&urlV7{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 去重限定在同一创建者且有效期相同的链接之间，按 (owner_id, canonical_hash) 联合索引查询
+type urlV8 struct {
+	ID            uint   `gorm:"primaryKey"`
+	CanonicalHash string `gorm:"size:64;index:idx_urls_owner_canonical,priority:2"`
+	OwnerID       string `gorm:"size:64;default:'';index:idx_urls_owner_canonical,priority:1"`
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	ExpiresIn     int64 `gorm:"default:0"`
+}
+
+func (urlV8) TableName() string { return "urls" }
+
+// addURLOwner 为 urls 增加 owner_id、expires_in 列，按创建和过期时间回填已有记录的有效期，
+// 并用 (owner_id, canonical_hash) 联合索引替换 canonical_hash 单列索引
+var addURLOwner = Migration{
+	Version: 8,
+	Name:    "add_url_owner",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"OwnerID", "ExpiresIn"} {
+			if tx.Migrator().HasColumn(&urlV8{}, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(&urlV8{}, field); err != nil {
+				return err
+			}
+		}
+
+		// 不过期的链接创建时设置为100年后过期，有效期超过99年的记为0
+		var batch []urlV8
+		err := tx.Where("expires_in = 0").
+			FindInBatches(&batch, 500, func(batchTx *gorm.DB, _ int) error {
+				for _, url := range batch {
+					expiresIn := int64(url.ExpiresAt.Sub(url.CreatedAt).Round(time.Second) / time.Second)
+					if expiresIn <= 0 || url.ExpiresAt.After(url.CreatedAt.AddDate(99, 0, 0)) {
+						continue
+					}
+					if err := tx.Model(&urlV8{}).Where("id = ?", url.ID).
+						Update("expires_in", expiresIn).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := createIndexIfMissing(tx, &urlV8{}, "idx_urls_owner_canonical"); err != nil {
+			return err
+		}
+		return dropIndexIfExists(tx, &urlV7{}, "idx_urls_canonical_hash")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := createIndexIfMissing(tx, &urlV7{}, "idx_urls_canonical_hash"); err != nil {
+			return err
+		}
+		if err := dropIndexIfExists(tx, &urlV8{}, "idx_urls_owner_canonical"); err != nil {
+			return err
+		}
+		for _, field := range []string{"ExpiresIn", "OwnerID"} {
+			if err := tx.Migrator().DropColumn(&urlV8{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
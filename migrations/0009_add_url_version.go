@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 修改链接时递增 version 做乐观并发控制；updated_at 用于从快照恢复过滤器后补充修改过的短码
+type urlV9 struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time `gorm:"index:idx_urls_updated_at"`
+	Version   int64     `gorm:"not null;default:1"`
+}
+
+func (urlV9) TableName() string { return "urls" }
+
+// addURLVersion 为 urls 增加 version、updated_at 列，已有记录的 updated_at 回填为创建时间
+var addURLVersion = Migration{
+	Version: 9,
+	Name:    "add_url_version",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Version", "UpdatedAt"} {
+			if tx.Migrator().HasColumn(&urlV9{}, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(&urlV9{}, field); err != nil {
+				return err
+			}
+		}
+		err := tx.Model(&urlV9{}).Where("updated_at IS NULL").
+			Update("updated_at", gorm.Expr("created_at")).Error
+		if err != nil {
+			return err
+		}
+		return createIndexIfMissing(tx, &urlV9{}, "idx_urls_updated_at")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dropIndexIfExists(tx, &urlV9{}, "idx_urls_updated_at"); err != nil {
+			return err
+		}
+		for _, field := range []string{"UpdatedAt", "Version"} {
+			if err := tx.Migrator().DropColumn(&urlV9{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 链接状态（active、disabled、deleted）及删除时间，删除为软删除，短码保留
+type urlV10 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Status    string `gorm:"size:16;not null;default:'active'"`
+	DeletedAt *time.Time
+}
+
+func (urlV10) TableName() string { return "urls" }
+
+// addURLStatus 为 urls 增加 status、deleted_at 列，已有记录为 active
+var addURLStatus = Migration{
+	Version: 10,
+	Name:    "add_url_status",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Status", "DeletedAt"} {
+			if tx.Migrator().HasColumn(&urlV10{}, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(&urlV10{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		for _, field := range []string{"DeletedAt", "Status"} {
+			if err := tx.Migrator().DropColumn(&urlV10{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/utils"
+	"gorm.io/gorm"
+)
+
+// 链接列表按标题和目标域名筛选、按创建时间、访问次数和最后访问时间排序
+type urlV11 struct {
+	ID              uint      `gorm:"primaryKey"`
+	OriginalURL     string    `gorm:"size:2048;not null"`
+	Title           string    `gorm:"size:255"`
+	DestinationHost string    `gorm:"size:255;index:idx_urls_destination_host"`
+	CreatedAt       time.Time `gorm:"index:idx_urls_created_at"`
+	AccessCount     int64     `gorm:"default:0;index:idx_urls_access_count"`
+	LastAccessAt    time.Time `gorm:"index:idx_urls_last_access_at"`
+	Status          string    `gorm:"size:16;not null;default:'active';index:idx_urls_status"`
+}
+
+func (urlV11) TableName() string { return "urls" }
+
+// 版本11时 url_tags 表的结构快照
+type urlTagV11 struct {
+	ID    uint   `gorm:"primaryKey"`
+	URLID uint   `gorm:"not null;uniqueIndex:idx_url_tags_tag_url,priority:2;index:idx_url_tags_url_id"`
+	Tag   string `gorm:"size:64;not null;uniqueIndex:idx_url_tags_tag_url,priority:1"`
+}
+
+func (urlTagV11) TableName() string { return "url_tags" }
+
+var urlV11Indexes = []string{
+	"idx_urls_destination_host",
+	"idx_urls_created_at",
+	"idx_urls_access_count",
+	"idx_urls_last_access_at",
+	"idx_urls_status",
+}
+
+// addLinkListing 为 urls 增加 title、destination_host 列和列表查询使用的索引，回填已有记录的目标域名，
+// 并创建 url_tags 表
+var addLinkListing = Migration{
+	Version: 11,
+	Name:    "add_link_listing",
+	Up: func(tx *gorm.DB) error {
+		for _, field := range []string{"Title", "DestinationHost"} {
+			if tx.Migrator().HasColumn(&urlV11{}, field) {
+				continue
+			}
+			if err := tx.Migrator().AddColumn(&urlV11{}, field); err != nil {
+				return err
+			}
+		}
+
+		// 分批回填已有记录的目标域名
+		var batch []urlV11
+		err := tx.Select("id", "original_url").Where("destination_host IS NULL OR destination_host = ''").
+			FindInBatches(&batch, 500, func(batchTx *gorm.DB, _ int) error {
+				for _, url := range batch {
+					if err := tx.Model(&urlV11{}).Where("id = ?", url.ID).
+						UpdateColumn("destination_host", utils.URLHost(url.OriginalURL)).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, name := range urlV11Indexes {
+			if err := createIndexIfMissing(tx, &urlV11{}, name); err != nil {
+				return err
+			}
+		}
+
+		if !tx.Migrator().HasTable(&urlTagV11{}) {
+			if err := tx.Migrator().CreateTable(&urlTagV11{}); err != nil {
+				return err
+			}
+		}
+		for _, name := range []string{"idx_url_tags_tag_url", "idx_url_tags_url_id"} {
+			if err := createIndexIfMissing(tx, &urlTagV11{}, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropTable(&urlTagV11{}); err != nil {
+			return err
+		}
+		for _, name := range urlV11Indexes {
+			if err := dropIndexIfExists(tx, &urlV11{}, name); err != nil {
+				return err
+			}
+		}
+		for _, field := range []string{"DestinationHost", "Title"} {
+			if err := tx.Migrator().DropColumn(&urlV11{}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+// all 全部迁移，新增迁移时追加到末尾，已发布的迁移不要修改
+var all = []Migration{
+	createURLTables,
+	addURLHash,
+	createSequences,
+	createShortCodeKeys,
+	widenShortCode,
+	addShortCodeCI,
+	addCanonicalURL,
+	addURLOwner,
+	addURLVersion,
+	addURLStatus,
+	addLinkListing,
+}
+
+// createIndexIfMissing 索引不存在时按模型定义创建索引
+func createIndexIfMissing(tx *gorm.DB, model interface{}, name string) error {
+	if tx.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(model, name)
+}
+
+// dropIndexIfExists 索引存在时删除索引
+func dropIndexIfExists(tx *gorm.DB, model interface{}, name string) error {
+	if !tx.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	return tx.Migrator().DropIndex(model, name)
+}
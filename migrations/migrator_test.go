@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB 创建临时SQLite数据库
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	return db
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	db := openTestDB(t)
+	migrator := NewMigrator(db)
+
+	count, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if count != len(all) {
+		t.Fatalf("Up() = %d, want %d", count, len(all))
+	}
+	if count, err := migrator.Up(); err != nil || count != 0 {
+		t.Fatalf("second Up() = %d, %v, want 0", count, err)
+	}
+
+	columns := []struct {
+		table  string
+		column string
+	}{
+		{"urls", "short_code"},
+		{"urls", "url_hash"},
+		{"urls", "short_code_ci"},
+		{"urls", "canonical_url"},
+		{"urls", "owner_id"},
+		{"urls", "version"},
+		{"urls", "status"},
+		{"urls", "destination_host"},
+		{"url_stats", "url_id"},
+		{"url_tags", "tag"},
+		{"sequences", "name"},
+		{"short_code_keys", "code"},
+	}
+	for _, c := range columns {
+		if !db.Migrator().HasColumn(c.table, c.column) {
+			t.Errorf("column %s.%s missing after Up", c.table, c.column)
+		}
+	}
+
+	// 回滚最近的 steps 个迁移后再执行 Up 应全部恢复
+	for steps := 1; steps <= len(all); steps++ {
+		if count, err := migrator.Down(steps); err != nil || count != steps {
+			t.Fatalf("Down(%d) = %d, %v", steps, count, err)
+		}
+		if count, err := migrator.Up(); err != nil || count != steps {
+			t.Fatalf("Up() after Down(%d) = %d, %v", steps, count, err)
+		}
+	}
+	if count, err := migrator.Down(len(all)); err != nil || count != len(all) {
+		t.Fatalf("Down(%d) = %d, %v", len(all), count, err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("migration %d still applied after rolling back all", status.Version)
+		}
+	}
+	for _, table := range []string{"urls", "url_stats", "url_tags"} {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("table %s still exists after rolling back all", table)
+		}
+	}
+}
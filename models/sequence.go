@@ -0,0 +1,7 @@
+package models
+
+// Sequence 号段分配使用的序列
+type Sequence struct {
+	Name      string `gorm:"primaryKey;size:64" json:"name"`
+	NextValue uint64 `gorm:"not null" json:"next_value"` // 下一个未分配的值
+}
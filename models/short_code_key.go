@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// ShortCodeKey 预生成的短码，领取后记录领取令牌和时间，不再分配
+type ShortCodeKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Code       string     `gorm:"size:64;not null;uniqueIndex:idx_short_code_keys_code" json:"code"`
+	ClaimToken *string    `gorm:"size:32;index:idx_short_code_keys_claim_token" json:"-"` // 为空表示未领取
+	ClaimedAt  *time.Time `json:"claimed_at"`
+}
@@ -4,24 +4,54 @@ import (
 	"time"
 )
 
+// 链接状态
+const (
+	// URLStatusActive 正常
+	URLStatusActive = "active"
+	// URLStatusDisabled 已停用，可重新启用
+	URLStatusDisabled = "disabled"
+	// URLStatusDeleted 已删除（软删除），短码保留不再分配
+	URLStatusDeleted = "deleted"
+)
+
 // URL 模型定义
 type URL struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	OriginalURL  string    `gorm:"size:2048;not null" json:"original_url"`
-	ShortCode    string    `gorm:"size:10;unique;not null" json:"short_code"`
-	CustomAlias  bool      `gorm:"default:false" json:"custom_alias"`
-	CreatedAt    time.Time `json:"created_at"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	AccessCount  int64     `gorm:"default:0" json:"access_count"`
-	LastAccessAt time.Time `json:"last_access_at"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	OriginalURL     string     `gorm:"size:2048;not null" json:"original_url"`
+	URLHash         string     `gorm:"size:64;index:idx_urls_url_hash" json:"-"`                                     // original_url 的SHA-256
+	CanonicalURL    string     `gorm:"size:2048" json:"-"`                                                           // 规范化后的URL
+	CanonicalHash   string     `gorm:"size:64;index:idx_urls_owner_canonical,priority:2" json:"-"`                   // canonical_url 的SHA-256，用于去重查询
+	OwnerID         string     `gorm:"size:64;default:'';index:idx_urls_owner_canonical,priority:1" json:"owner_id"` // 创建者，只在同一创建者的链接间去重
+	Title           string     `gorm:"size:255" json:"title"`
+	DestinationHost string     `gorm:"size:255;index:idx_urls_destination_host" json:"-"` // 目标URL的主机名（小写），用于按域名筛选
+	ShortCode       string     `gorm:"size:64;not null;uniqueIndex:idx_urls_short_code" json:"short_code"`
+	ShortCodeCI     *string    `gorm:"size:64;uniqueIndex:idx_urls_short_code_ci" json:"-"` // 小写短码，仅大小写不敏感模式下写入
+	CustomAlias     bool       `gorm:"default:false" json:"custom_alias"`
+	CreatedAt       time.Time  `gorm:"index:idx_urls_created_at" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"index:idx_urls_updated_at" json:"updated_at"`
+	ExpiresAt       time.Time  `gorm:"index:idx_urls_expires_at" json:"expires_at"`
+	ExpiresIn       int64      `gorm:"default:0" json:"expires_in"` // 创建时请求的有效期（秒），0 表示不过期
+	AccessCount     int64      `gorm:"default:0;index:idx_urls_access_count" json:"access_count"`
+	LastAccessAt    time.Time  `gorm:"index:idx_urls_last_access_at" json:"last_access_at"`
+	Version         int64      `gorm:"not null;default:1" json:"version"` // 每次修改加1，用于乐观并发控制
+	Status          string     `gorm:"size:16;not null;default:'active';index:idx_urls_status" json:"status"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	Tags            []string   `gorm:"-" json:"tags,omitempty"` // 保存在 url_tags 表中
+}
+
+// URLTag 链接标签
+type URLTag struct {
+	ID    uint   `gorm:"primaryKey" json:"id"`
+	URLID uint   `gorm:"not null;uniqueIndex:idx_url_tags_tag_url,priority:2;index:idx_url_tags_url_id" json:"url_id"`
+	Tag   string `gorm:"size:64;not null;uniqueIndex:idx_url_tags_tag_url,priority:1" json:"tag"`
 }
 
 // URLStats 访问统计
 type URLStats struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	URLID     uint      `json:"url_id"`
+	URLID     uint      `gorm:"index:idx_url_stats_url_access,priority:1" json:"url_id"`
 	AccessIP  string    `gorm:"size:50" json:"access_ip"`
 	UserAgent string    `gorm:"size:512" json:"user_agent"`
 	Referer   string    `gorm:"size:512" json:"referer"`
-	AccessAt  time.Time `json:"access_at"`
+	AccessAt  time.Time `gorm:"index:idx_url_stats_url_access,priority:2" json:"access_at"`
 }
@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormURLRepository 基于GORM的短链接存储
+type GormURLRepository struct {
+	db *gorm.DB
+	// caseInsensitive 大小写不敏感模式：按 short_code_ci（小写短码，唯一索引）查询和判重
+	caseInsensitive bool
+}
+
+// NewGormURLRepository 创建基于GORM的短链接存储
+func NewGormURLRepository(db *gorm.DB) *GormURLRepository {
+	return &GormURLRepository{db: db}
+}
+
+// EnableCaseInsensitive 开启大小写不敏感模式：为缺少 short_code_ci 的记录回填小写短码，
+// 之后按小写短码查询，唯一索引保证短码忽略大小写后不重复。存在仅大小写不同的短码时返回 ErrDuplicateKey
+func (r *GormURLRepository) EnableCaseInsensitive() error {
+	err := r.db.Model(&models.URL{}).Where("short_code_ci IS NULL").
+		UpdateColumn("short_code_ci", gorm.Expr("LOWER(short_code)")).Error
+	if err != nil {
+		return translateError(err)
+	}
+	r.caseInsensitive = true
+	return nil
+}
+
+// BackfillCanonicalURLs 为缺少 canonical_hash 的记录按 normalize 回填规范化URL，返回回填数量
+func (r *GormURLRepository) BackfillCanonicalURLs(normalize func(string) string, batchSize int) (int, error) {
+	filled := 0
+	var batch []models.URL
+	err := r.db.Model(&models.URL{}).Select("id", "original_url").
+		Where("canonical_hash IS NULL OR canonical_hash = ''").
+		FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+			for _, url := range batch {
+				canonicalURL := normalize(url.OriginalURL)
+				err := r.db.Model(&models.URL{}).Where("id = ?", url.ID).UpdateColumns(map[string]interface{}{
+					"canonical_url":  canonicalURL,
+					"canonical_hash": utils.HashURL(canonicalURL),
+				}).Error
+				if err != nil {
+					return err
+				}
+				filled++
+			}
+			return nil
+		}).Error
+	return filled, err
+}
+
+// codeColumn 返回按短码查询使用的列和参数
+func (r *GormURLRepository) codeColumn(shortCode string) (string, string) {
+	if r.caseInsensitive {
+		return "short_code_ci", strings.ToLower(shortCode)
+	}
+	return "short_code", shortCode
+}
+
+// FindByShortCode 根据短码查询
+func (r *GormURLRepository) FindByShortCode(shortCode string) (*models.URL, error) {
+	var url models.URL
+	column, code := r.codeColumn(shortCode)
+	if err := r.db.Where(column+" = ?", code).First(&url).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &url, nil
+}
+
+// FindReusable 查询可复用的记录，先按 (owner_id, canonical_hash) 索引定位再比较原文
+func (r *GormURLRepository) FindReusable(ownerID, canonicalURL string, expiresIn int64, minExpiresAt time.Time) (*models.URL, error) {
+	var url models.URL
+	err := r.db.Where("owner_id = ? AND canonical_hash = ? AND canonical_url = ?",
+		ownerID, utils.HashURL(canonicalURL), canonicalURL).
+		Where("custom_alias = ? AND status = ? AND expires_in = ? AND expires_at > ?",
+			false, models.URLStatusActive, expiresIn, minExpiresAt).
+		Last(&url).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &url, nil
+}
+
+// Create 在事务中创建短链接记录及其标签，短码已存在时返回 ErrDuplicateKey
+func (r *GormURLRepository) Create(url *models.URL) error {
+	url.URLHash = utils.HashURL(url.OriginalURL)
+	if url.CanonicalURL == "" {
+		url.CanonicalURL = utils.NormalizeURL(url.OriginalURL)
+	}
+	url.CanonicalHash = utils.HashURL(url.CanonicalURL)
+	url.DestinationHost = utils.URLHost(url.OriginalURL)
+	url.Version = 1
+	if url.Status == "" {
+		url.Status = models.URLStatusActive
+	}
+	if r.caseInsensitive {
+		lower := strings.ToLower(url.ShortCode)
+		url.ShortCodeCI = &lower
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(url).Error; err != nil {
+			return err
+		}
+		return insertTags(tx, url.ID, url.Tags)
+	})
+	return translateError(err)
+}
+
+// insertTags 插入链接的标签
+func insertTags(tx *gorm.DB, urlID uint, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]models.URLTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = models.URLTag{URLID: urlID, Tag: tag}
+	}
+	return tx.Create(&rows).Error
+}
+
+// SetTags 在事务中替换链接的标签
+func (r *GormURLRepository) SetTags(urlID uint, tags []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("url_id = ?", urlID).Delete(&models.URLTag{}).Error; err != nil {
+			return err
+		}
+		return insertTags(tx, urlID, tags)
+	})
+}
+
+// TagsOf 返回各链接的标签，按标签名排序
+func (r *GormURLRepository) TagsOf(urlIDs []uint) (map[uint][]string, error) {
+	tags := make(map[uint][]string)
+	if len(urlIDs) == 0 {
+		return tags, nil
+	}
+	var rows []models.URLTag
+	if err := r.db.Where("url_id IN ?", urlIDs).Order("tag").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.URLID] = append(tags[row.URLID], row.Tag)
+	}
+	return tags, nil
+}
+
+// sortColumns 允许的排序字段
+var sortColumns = map[string]bool{
+	SortCreatedAt:    true,
+	SortAccessCount:  true,
+	SortLastAccessAt: true,
+}
+
+// List 按条件查询链接列表，使用 (排序字段, id) 键集分页，翻页时不需要 OFFSET
+func (r *GormURLRepository) List(query URLQuery) ([]models.URL, error) {
+	db := r.db.Model(&models.URL{})
+	if query.OwnerID != nil {
+		db = db.Where("owner_id = ?", *query.OwnerID)
+	}
+	if query.Tag != "" {
+		db = db.Where("id IN (?)", r.db.Model(&models.URLTag{}).Select("url_id").Where("tag = ?", query.Tag))
+	}
+	if query.Host != "" {
+		db = db.Where("destination_host = ?", query.Host)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("status IN ?", query.Statuses)
+	}
+	if !query.CreatedAfter.IsZero() {
+		db = db.Where("created_at >= ?", query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		db = db.Where("created_at < ?", query.CreatedBefore)
+	}
+	if !query.ExpiresAfter.IsZero() {
+		db = db.Where("expires_at >= ?", query.ExpiresAfter)
+	}
+	if !query.ExpiresBefore.IsZero() {
+		db = db.Where("expires_at < ?", query.ExpiresBefore)
+	}
+	if query.Search != "" {
+		// 使用 ! 作为转义字符，各数据库对反斜杠的处理不一致
+		pattern := "%" + escapeLike(strings.ToLower(query.Search)) + "%"
+		db = db.Where("(LOWER(original_url) LIKE ? ESCAPE '!' OR LOWER(title) LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+
+	column := query.Sort
+	if !sortColumns[column] {
+		column = SortCreatedAt
+	}
+	op, order := ">", "ASC"
+	if query.Desc {
+		op, order = "<", "DESC"
+	}
+	if query.After != nil {
+		var value interface{} = query.After.Time
+		if column == SortAccessCount {
+			value = query.After.Count
+		}
+		db = db.Where(fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?)", column, op),
+			value, value, query.After.ID)
+	}
+
+	var urls []models.URL
+	err := db.Order(column + " " + order).Order("id " + order).Limit(query.Limit).Find(&urls).Error
+	return urls, err
+}
+
+// escapeLike 转义 LIKE 模式中的特殊字符，转义字符为 !
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// Update 按 url.Version 乐观地更新目标URL、标题、短码、过期时间和状态，成功后 url.Version 加1
+func (r *GormURLRepository) Update(url *models.URL) error {
+	url.URLHash = utils.HashURL(url.OriginalURL)
+	url.CanonicalHash = utils.HashURL(url.CanonicalURL)
+	url.DestinationHost = utils.URLHost(url.OriginalURL)
+	url.UpdatedAt = time.Now()
+	var shortCodeCI *string
+	if r.caseInsensitive {
+		lower := strings.ToLower(url.ShortCode)
+		shortCodeCI = &lower
+	}
+
+	result := r.db.Model(&models.URL{}).
+		Where("id = ? AND version = ?", url.ID, url.Version).
+		UpdateColumns(map[string]interface{}{
+			"original_url":     url.OriginalURL,
+			"url_hash":         url.URLHash,
+			"canonical_url":    url.CanonicalURL,
+			"canonical_hash":   url.CanonicalHash,
+			"destination_host": url.DestinationHost,
+			"title":            url.Title,
+			"short_code":       url.ShortCode,
+			"short_code_ci":    shortCodeCI,
+			"custom_alias":     url.CustomAlias,
+			"expires_at":       url.ExpiresAt,
+			"expires_in":       url.ExpiresIn,
+			"status":           url.Status,
+			"deleted_at":       url.DeletedAt,
+			"updated_at":       url.UpdatedAt,
+			"version":          gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	url.ShortCodeCI = shortCodeCI
+	url.Version++
+	return nil
+}
+
+// ExistingShortCodes 返回 shortCodes 中已被使用的短码
+func (r *GormURLRepository) ExistingShortCodes(shortCodes []string) ([]string, error) {
+	var existing []string
+	if len(shortCodes) == 0 {
+		return existing, nil
+	}
+	column := "short_code"
+	if r.caseInsensitive {
+		column = "short_code_ci"
+		lowered := make([]string, len(shortCodes))
+		for i, code := range shortCodes {
+			lowered[i] = strings.ToLower(code)
+		}
+		shortCodes = lowered
+	}
+	err := r.db.Model(&models.URL{}).Where(column+" IN ?", shortCodes).Pluck(column, &existing).Error
+	return existing, err
+}
+
+// IncrementAccess 增加访问计数并更新最后访问时间，不修改 updated_at
+func (r *GormURLRepository) IncrementAccess(shortCode string, accessAt time.Time) error {
+	column, code := r.codeColumn(shortCode)
+	return r.db.Model(&models.URL{}).
+		Where(column+" = ?", code).
+		UpdateColumns(map[string]interface{}{
+			"access_count":   gorm.Expr("access_count + 1"),
+			"last_access_at": accessAt,
+		}).Error
+}
+
+// DeleteExpired 删除在指定时间之前过期的记录，返回被删除的短码。已软删除的记录保留，其短码不再分配。
+// 在同一事务中锁定选出的行（SQLite只有一个连接，事务本身即串行），删除时再次检查过期时间，
+// 选出之后被并发修改延长了有效期的记录不会被删除
+func (r *GormURLRepository) DeleteExpired(before time.Time) ([]string, error) {
+	var shortCodes []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var expired []models.URL
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "short_code").
+			Where("expires_at < ? AND status <> ?", before, models.URLStatusDeleted).Find(&expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(expired))
+		shortCodes = make([]string, len(expired))
+		for i, url := range expired {
+			ids[i] = url.ID
+			shortCodes[i] = url.ShortCode
+		}
+		if err := tx.Where("url_id IN ?", ids).Delete(&models.URLTag{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id IN ? AND expires_at < ? AND status <> ?", ids, before, models.URLStatusDeleted).
+			Delete(&models.URL{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			// 不应发生：行已锁定。回滚后由下次清理重试，避免从过滤器中移除仍然存在的短码
+			return fmt.Errorf("过期记录在删除期间被修改: 选出 %d 条，删除 %d 条", len(ids), result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shortCodes, nil
+}
+
+// EachShortCode 分批遍历已存储的短码（包括已过期但尚未删除的，保证计数过滤器删除时计数一致），
+// 按主键分页避免一次性加载全表
+func (r *GormURLRepository) EachShortCode(changedSince time.Time, batchSize int, fn func(shortCodes []string) error) error {
+	query := r.db.Model(&models.URL{}).Select("id", "short_code")
+	if !changedSince.IsZero() {
+		query = query.Where("updated_at >= ?", changedSince)
+	}
+
+	var batch []models.URL
+	return query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		shortCodes := make([]string, len(batch))
+		for i, url := range batch {
+			shortCodes[i] = url.ShortCode
+		}
+		return fn(shortCodes)
+	}).Error
+}
+
+// GormSequenceRepository 基于GORM的序列存储
+type GormSequenceRepository struct {
+	db *gorm.DB
+}
+
+// NewGormSequenceRepository 创建基于GORM的序列存储
+func NewGormSequenceRepository(db *gorm.DB) *GormSequenceRepository {
+	return &GormSequenceRepository{db: db}
+}
+
+// NextBlock 在事务中递增序列并读回结果，UPDATE持有的行锁保证多实例并发分配的号段不重叠
+func (r *GormSequenceRepository) NextBlock(name string, size uint64) (uint64, error) {
+	var seq models.Sequence
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 序列不存在时从1开始
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Sequence{Name: name, NextValue: 1}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Sequence{}).Where("name = ?", name).
+			Update("next_value", gorm.Expr("next_value + ?", size)).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", name).First(&seq).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return seq.NextValue - size, nil
+}
+
+// GormKeyPoolRepository 基于GORM的预生成短码池，领取记录保留在表中，短码不会被重复加入
+type GormKeyPoolRepository struct {
+	db *gorm.DB
+}
+
+// NewGormKeyPoolRepository 创建基于GORM的短码池
+func NewGormKeyPoolRepository(db *gorm.DB) *GormKeyPoolRepository {
+	return &GormKeyPoolRepository{db: db}
+}
+
+// Add 将短码加入池中，已在池中的短码忽略
+func (r *GormKeyPoolRepository) Add(codes []string) (int, error) {
+	if len(codes) == 0 {
+		return 0, nil
+	}
+	keys := make([]models.ShortCodeKey, len(codes))
+	for i, code := range codes {
+		keys[i] = models.ShortCodeKey{Code: code}
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&keys)
+	return int(result.RowsAffected), result.Error
+}
+
+// Claim 乐观地领取短码：先选出候选行，再以本次领取的令牌更新其中仍未被领取的行，
+// 最后按令牌读回实际领取到的短码。与其他实例竞争时只领到部分或全部落空，继续领取剩余个数，
+// 直到领满 n 个或池中已没有未领取的短码
+func (r *GormKeyPoolRepository) Claim(n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	token := newClaimToken()
+	claimed := 0
+	for claimed < n {
+		var ids []uint
+		err := r.db.Model(&models.ShortCodeKey{}).Where("claim_token IS NULL").
+			Order("id").Limit(n-claimed).Pluck("id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		result := r.db.Model(&models.ShortCodeKey{}).
+			Where("id IN ? AND claim_token IS NULL", ids).
+			Updates(map[string]interface{}{"claim_token": token, "claimed_at": time.Now()})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		claimed += int(result.RowsAffected)
+	}
+	if claimed == 0 {
+		return nil, nil
+	}
+
+	var codes []string
+	err := r.db.Model(&models.ShortCodeKey{}).Where("claim_token = ?", token).Pluck("code", &codes).Error
+	return codes, err
+}
+
+// Available 池中未领取的短码个数
+func (r *GormKeyPoolRepository) Available() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ShortCodeKey{}).Where("claim_token IS NULL").Count(&count).Error
+	return count, err
+}
+
+// newClaimToken 生成随机领取令牌
+func newClaimToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GormStatsRepository 基于GORM的访问统计存储
+type GormStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewGormStatsRepository 创建基于GORM的访问统计存储
+func NewGormStatsRepository(db *gorm.DB) *GormStatsRepository {
+	return &GormStatsRepository{db: db}
+}
+
+// Create 记录一次访问
+func (r *GormStatsRepository) Create(stats *models.URLStats) error {
+	return r.db.Create(stats).Error
+}
+
+// DailyCounts 按天汇总指定时间之后的访问次数
+func (r *GormStatsRepository) DailyCounts(urlID uint, since time.Time) ([]DailyCount, error) {
+	day := dayExpr(r.db, "access_at")
+	rows, err := r.db.Raw(`
+		SELECT `+day+` as date, COUNT(*) as count 
+		FROM url_stats 
+		WHERE url_id = ? AND access_at > ?
+		GROUP BY `+day+`
+		ORDER BY date DESC
+	`, urlID, since).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []DailyCount
+	for rows.Next() {
+		var count DailyCount
+		if err := rows.Scan(&count.Date, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// dayExpr 返回将时间列格式化为 YYYY-MM-DD 字符串的方言相关表达式
+func dayExpr(db *gorm.DB, column string) string {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return "strftime('%Y-%m-%d', " + column + ", 'localtime')"
+	case "postgres":
+		return "TO_CHAR(" + column + ", 'YYYY-MM-DD')"
+	default:
+		return "DATE_FORMAT(" + column + ", '%Y-%m-%d')"
+	}
+}
+
+// translateError 将GORM错误转换为存储层错误
+func translateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateKey
+	}
+	return err
+}
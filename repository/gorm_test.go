@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/keenJoe/go-url-shortener/migrations"
+	"github.com/keenJoe/go-url-shortener/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestGormRepository 在临时SQLite数据库上执行全部迁移并创建存储
+func newTestGormRepository(t *testing.T) *GormURLRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewGormURLRepository(db)
+}
+
+func TestGormDeleteExpired(t *testing.T) {
+	r := newTestGormRepository(t)
+	now := time.Now()
+	links := []struct {
+		code      string
+		expiresAt time.Time
+		status    string
+		wantGone  bool
+	}{
+		{code: "expired", expiresAt: now.Add(-time.Hour), status: models.URLStatusActive, wantGone: true},
+		{code: "disabled", expiresAt: now.Add(-time.Hour), status: models.URLStatusDisabled, wantGone: true},
+		{code: "tombstone", expiresAt: now.Add(-time.Hour), status: models.URLStatusDeleted},
+		{code: "valid", expiresAt: now.Add(time.Hour), status: models.URLStatusActive},
+	}
+	for _, link := range links {
+		url := &models.URL{
+			OriginalURL: "https://example.com/" + link.code,
+			ShortCode:   link.code,
+			CreatedAt:   now.Add(-2 * time.Hour),
+			ExpiresAt:   link.expiresAt,
+			Status:      link.status,
+			Tags:        []string{"t"},
+		}
+		if err := r.Create(url); err != nil {
+			t.Fatalf("Create(%s) error = %v", link.code, err)
+		}
+	}
+
+	deleted, err := r.DeleteExpired(now)
+	if err != nil {
+		t.Fatalf("DeleteExpired() error = %v", err)
+	}
+	slices.Sort(deleted)
+	if want := []string{"disabled", "expired"}; !slices.Equal(deleted, want) {
+		t.Errorf("DeleteExpired() = %v, want %v", deleted, want)
+	}
+	for _, link := range links {
+		_, err := r.FindByShortCode(link.code)
+		if gone := err == ErrNotFound; gone != link.wantGone {
+			t.Errorf("%s: deleted = %v, want %v", link.code, gone, link.wantGone)
+		}
+	}
+	var tags int64
+	r.db.Model(&models.URLTag{}).Count(&tags)
+	if tags != 2 {
+		t.Errorf("url_tags rows = %d, want 2", tags)
+	}
+
+	if deleted, err := r.DeleteExpired(now); err != nil || len(deleted) != 0 {
+		t.Errorf("second DeleteExpired() = %v, %v, want none", deleted, err)
+	}
+}
+
+func TestGormFindReusable(t *testing.T) {
+	r := newTestGormRepository(t)
+	now := time.Now()
+	links := []struct {
+		code      string
+		ownerID   string
+		alias     bool
+		expiresIn int64
+		expiresAt time.Time
+		status    string
+	}{
+		{code: "other", ownerID: "other", expiresIn: 3600, expiresAt: now.Add(time.Hour), status: models.URLStatusActive},
+		{code: "alias", alias: true, expiresIn: 3600, expiresAt: now.Add(time.Hour), status: models.URLStatusActive},
+		{code: "disabled", expiresIn: 3600, expiresAt: now.Add(time.Hour), status: models.URLStatusDisabled},
+		{code: "day", expiresIn: 86400, expiresAt: now.Add(time.Hour), status: models.URLStatusActive},
+		{code: "older", expiresIn: 3600, expiresAt: now.Add(30 * time.Minute), status: models.URLStatusActive},
+		{code: "newer", expiresIn: 3600, expiresAt: now.Add(time.Hour), status: models.URLStatusActive},
+	}
+	for _, link := range links {
+		url := &models.URL{
+			OriginalURL: "https://example.com/",
+			OwnerID:     link.ownerID,
+			ShortCode:   link.code,
+			CustomAlias: link.alias,
+			CreatedAt:   now,
+			ExpiresIn:   link.expiresIn,
+			ExpiresAt:   link.expiresAt,
+			Status:      link.status,
+		}
+		if err := r.Create(url); err != nil {
+			t.Fatalf("Create(%s) error = %v", link.code, err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		expiresIn    int64
+		minExpiresAt time.Time
+		want         string
+	}{
+		{name: "newest match", expiresIn: 3600, minExpiresAt: now, want: "newer"},
+		{name: "remaining lifetime", expiresIn: 3600, minExpiresAt: now.Add(59 * time.Minute), want: "newer"},
+		{name: "lifetime not covered", expiresIn: 3600, minExpiresAt: now.Add(2 * time.Hour)},
+		{name: "different expires_in", expiresIn: 60, minExpiresAt: now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := r.FindReusable("", "https://example.com/", tt.expiresIn, tt.minExpiresAt)
+			if tt.want == "" {
+				if err != ErrNotFound {
+					t.Errorf("FindReusable() = %v, %v, want ErrNotFound", url, err)
+				}
+				return
+			}
+			if err != nil || url.ShortCode != tt.want {
+				t.Errorf("FindReusable() = %v, %v, want %s", url, err, tt.want)
+			}
+		})
+	}
+}
+
+// listSortKey 返回链接的排序字段值，用于检查翻页结果的顺序
+func listSortKey(url *models.URL, sortBy string) int64 {
+	switch sortBy {
+	case SortAccessCount:
+		return url.AccessCount
+	case SortLastAccessAt:
+		return url.LastAccessAt.UnixNano()
+	default:
+		return url.CreatedAt.UnixNano()
+	}
+}
+
+func TestGormListPaging(t *testing.T) {
+	const total = 13
+	r := newTestGormRepository(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < total+2; i++ {
+		url := &models.URL{
+			OriginalURL: "https://example.com/" + strconv.Itoa(i),
+			OwnerID:     "alice",
+			ShortCode:   "link" + strconv.Itoa(i),
+			CreatedAt:   base.Add(time.Duration(i/3) * time.Minute),
+			ExpiresAt:   base.AddDate(100, 0, 0),
+			AccessCount: int64(i % 4),
+		}
+		if i%2 == 0 {
+			url.LastAccessAt = base.Add(time.Duration(i%5) * time.Hour)
+		}
+		// 最后两条分别属于其他创建者和已删除，不应出现在结果中
+		switch i {
+		case total:
+			url.OwnerID = "bob"
+		case total + 1:
+			url.Status = models.URLStatusDeleted
+		}
+		if err := r.Create(url); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	owner := "alice"
+	for _, sortBy := range []string{SortCreatedAt, SortAccessCount, SortLastAccessAt} {
+		for _, desc := range []bool{false, true} {
+			t.Run(sortBy+"/"+strconv.FormatBool(desc), func(t *testing.T) {
+				query := URLQuery{
+					OwnerID:  &owner,
+					Statuses: []string{models.URLStatusActive},
+					Sort:     sortBy,
+					Desc:     desc,
+					Limit:    4,
+				}
+				var (
+					codes  []string
+					listed []*models.URL
+				)
+				for n := 0; n <= total; n++ {
+					page, err := r.List(query)
+					if err != nil {
+						t.Fatalf("List() error = %v", err)
+					}
+					if len(page) == 0 {
+						break
+					}
+					for i := range page {
+						codes = append(codes, page[i].ShortCode)
+						listed = append(listed, &page[i])
+					}
+					last := page[len(page)-1]
+					query.After = &URLCursor{Time: last.CreatedAt, Count: last.AccessCount, ID: last.ID}
+					if sortBy == SortLastAccessAt {
+						query.After.Time = last.LastAccessAt
+					}
+				}
+
+				for i := 1; i < len(listed); i++ {
+					prev, url := listed[i-1], listed[i]
+					a, b := listSortKey(prev, sortBy), listSortKey(url, sortBy)
+					ordered := a < b || a == b && prev.ID < url.ID
+					if desc {
+						ordered = a > b || a == b && prev.ID > url.ID
+					}
+					if !ordered {
+						t.Errorf("%s returned after %s", url.ShortCode, prev.ShortCode)
+					}
+				}
+
+				want := make([]string, total)
+				for i := range want {
+					want[i] = "link" + strconv.Itoa(i)
+				}
+				got := slices.Clone(codes)
+				slices.Sort(got)
+				slices.Sort(want)
+				if !slices.Equal(got, want) {
+					t.Errorf("List() pages = %v, want each of %v once", codes, want)
+				}
+			})
+		}
+	}
+}
+
+func TestGormDailyCounts(t *testing.T) {
+	r := NewGormStatsRepository(newTestGormRepository(t).db)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	accesses := []struct {
+		urlID    uint
+		accessAt time.Time
+	}{
+		{urlID: 1, accessAt: day.Add(-time.Hour)}, // since 之前
+		{urlID: 1, accessAt: day.Add(30 * time.Minute)},
+		{urlID: 1, accessAt: day.Add(23*time.Hour + 59*time.Minute)},
+		{urlID: 1, accessAt: day.Add(24 * time.Hour)},
+		{urlID: 1, accessAt: day.Add(50 * time.Hour)},
+		{urlID: 1, accessAt: day.Add(51 * time.Hour)},
+		{urlID: 1, accessAt: day.Add(52 * time.Hour)},
+		{urlID: 2, accessAt: day.Add(time.Hour)},
+	}
+	for _, access := range accesses {
+		if err := r.Create(&models.URLStats{URLID: access.urlID, AccessAt: access.accessAt}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	counts, err := r.DailyCounts(1, day)
+	if err != nil {
+		t.Fatalf("DailyCounts() error = %v", err)
+	}
+	want := []DailyCount{{Date: "2026-03-03", Count: 3}, {Date: "2026-03-02", Count: 1}, {Date: "2026-03-01", Count: 2}}
+	if !slices.Equal(counts, want) {
+		t.Errorf("DailyCounts() = %v, want %v", counts, want)
+	}
+}
+
+func TestDayExpr(t *testing.T) {
+	tests := []struct {
+		dialector gorm.Dialector
+		want      string
+	}{
+		{dialector: sqlite.Open(""), want: "strftime('%Y-%m-%d', access_at, 'localtime')"},
+		{dialector: postgres.Open(""), want: "TO_CHAR(access_at, 'YYYY-MM-DD')"},
+		{dialector: mysql.Open(""), want: "DATE_FORMAT(access_at, '%Y-%m-%d')"},
+	}
+	for _, tt := range tests {
+		db := &gorm.DB{Config: &gorm.Config{Dialector: tt.dialector}}
+		if got := dayExpr(db, "access_at"); got != tt.want {
+			t.Errorf("dayExpr(%s) = %s, want %s", tt.dialector.Name(), got, tt.want)
+		}
+	}
+}
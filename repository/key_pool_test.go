@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestKeyPoolClaimConcurrent(t *testing.T) {
+	tests := []struct {
+		name     string
+		newStore func(t *testing.T) KeyPoolRepository
+	}{
+		{name: "memory", newStore: func(t *testing.T) KeyPoolRepository { return NewMemoryKeyPoolRepository() }},
+		{name: "sqlite", newStore: func(t *testing.T) KeyPoolRepository {
+			db := newTestGormRepository(t).db
+			sqlDB, err := db.DB()
+			if err != nil {
+				t.Fatalf("db.DB() error = %v", err)
+			}
+			// 与 InitDB 一致，SQLite只使用一个连接
+			sqlDB.SetMaxOpenConns(1)
+			return NewGormKeyPoolRepository(db)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.newStore(t)
+			const total = 500
+			codes := make([]string, total)
+			for i := range codes {
+				codes[i] = fmt.Sprintf("k%06d", i)
+			}
+			if added, err := store.Add(codes); err != nil || added != total {
+				t.Fatalf("Add() = %d, %v, want %d", added, err, total)
+			}
+			if added, err := store.Add(codes[:10]); err != nil || added != 0 {
+				t.Errorf("Add() of pooled codes = %d, %v, want 0", added, err)
+			}
+
+			var (
+				mu      sync.Mutex
+				claimed = make(map[string]int)
+				wg      sync.WaitGroup
+			)
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						got, err := store.Claim(7)
+						if err != nil {
+							t.Errorf("Claim() error = %v", err)
+							return
+						}
+						if len(got) == 0 {
+							// 竞争失败不能表现为池已空
+							if available, err := store.Available(); err != nil || available != 0 {
+								t.Errorf("Claim() returned nothing with %d codes available (err %v)", available, err)
+							}
+							return
+						}
+						mu.Lock()
+						for _, code := range got {
+							claimed[code]++
+						}
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			if len(claimed) != total {
+				t.Errorf("claimed %d distinct codes, want %d", len(claimed), total)
+			}
+			for code, n := range claimed {
+				if n > 1 {
+					t.Errorf("code %q claimed %d times", code, n)
+				}
+			}
+			if available, err := store.Available(); err != nil || available != 0 {
+				t.Errorf("Available() = %d, %v, want 0", available, err)
+			}
+		})
+	}
+}
+
+func TestKeyPoolClaimFillsBatch(t *testing.T) {
+	store := NewGormKeyPoolRepository(newTestGormRepository(t).db)
+	codes := make([]string, 20)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("k%06d", i)
+	}
+	if _, err := store.Add(codes); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	for _, want := range []int{8, 8, 4, 0} {
+		got, err := store.Claim(8)
+		if err != nil || len(got) != want {
+			t.Errorf("Claim(8) = %v, %v, want %d codes", got, err, want)
+		}
+	}
+}
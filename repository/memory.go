@@ -0,0 +1,466 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// MemoryURLRepository 内存短链接存储，用于测试
+type MemoryURLRepository struct {
+	mu              sync.RWMutex
+	nextID          uint
+	urls            map[string]*models.URL // short_code（大小写不敏感模式下为小写） -> URL
+	caseInsensitive bool
+}
+
+// NewMemoryURLRepository 创建内存短链接存储
+func NewMemoryURLRepository() *MemoryURLRepository {
+	return &MemoryURLRepository{
+		urls: make(map[string]*models.URL),
+	}
+}
+
+// EnableCaseInsensitive 开启大小写不敏感模式，存在仅大小写不同的短码时返回 ErrDuplicateKey
+func (r *MemoryURLRepository) EnableCaseInsensitive() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	urls := make(map[string]*models.URL, len(r.urls))
+	for code, url := range r.urls {
+		lower := strings.ToLower(code)
+		if _, exists := urls[lower]; exists {
+			return ErrDuplicateKey
+		}
+		urls[lower] = url
+	}
+	r.urls = urls
+	r.caseInsensitive = true
+	return nil
+}
+
+// key 返回短码在 urls 中的键
+func (r *MemoryURLRepository) key(shortCode string) string {
+	if r.caseInsensitive {
+		return strings.ToLower(shortCode)
+	}
+	return shortCode
+}
+
+// FindByShortCode 根据短码查询
+func (r *MemoryURLRepository) FindByShortCode(shortCode string) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	url, ok := r.urls[r.key(shortCode)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *url
+	return &copied, nil
+}
+
+// FindReusable 查询可复用的记录，多条匹配时返回ID最大的一条
+func (r *MemoryURLRepository) FindReusable(ownerID, canonicalURL string, expiresIn int64, minExpiresAt time.Time) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.URL
+	for _, url := range r.urls {
+		reusable := url.OwnerID == ownerID && url.CanonicalURL == canonicalURL && !url.CustomAlias &&
+			url.Status == models.URLStatusActive && url.ExpiresIn == expiresIn && url.ExpiresAt.After(minExpiresAt)
+		if reusable && (found == nil || url.ID > found.ID) {
+			found = url
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	copied := *found
+	return &copied, nil
+}
+
+// Create 创建短链接记录
+func (r *MemoryURLRepository) Create(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := r.key(url.ShortCode)
+	if _, exists := r.urls[key]; exists {
+		return ErrDuplicateKey
+	}
+
+	r.nextID++
+	url.ID = r.nextID
+	url.URLHash = utils.HashURL(url.OriginalURL)
+	if url.CanonicalURL == "" {
+		url.CanonicalURL = utils.NormalizeURL(url.OriginalURL)
+	}
+	url.CanonicalHash = utils.HashURL(url.CanonicalURL)
+	url.DestinationHost = utils.URLHost(url.OriginalURL)
+	url.Version = 1
+	if url.Status == "" {
+		url.Status = models.URLStatusActive
+	}
+	if url.UpdatedAt.IsZero() {
+		url.UpdatedAt = url.CreatedAt
+	}
+	copied := *url
+	copied.Tags = sortedTags(url.Tags)
+	r.urls[key] = &copied
+	return nil
+}
+
+// sortedTags 返回排序后的标签副本
+func sortedTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// SetTags 替换链接的标签
+func (r *MemoryURLRepository) SetTags(urlID uint, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, url := range r.urls {
+		if url.ID == urlID {
+			url.Tags = sortedTags(tags)
+		}
+	}
+	return nil
+}
+
+// TagsOf 返回各链接的标签，按标签名排序
+func (r *MemoryURLRepository) TagsOf(urlIDs []uint) (map[uint][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[uint]bool, len(urlIDs))
+	for _, id := range urlIDs {
+		wanted[id] = true
+	}
+	tags := make(map[uint][]string)
+	for _, url := range r.urls {
+		if wanted[url.ID] && len(url.Tags) > 0 {
+			tags[url.ID] = append([]string(nil), url.Tags...)
+		}
+	}
+	return tags, nil
+}
+
+// List 按条件查询链接列表
+func (r *MemoryURLRepository) List(query URLQuery) ([]models.URL, error) {
+	r.mu.RLock()
+	var urls []models.URL
+	for _, url := range r.urls {
+		if matchesQuery(url, query) {
+			copied := *url
+			copied.Tags = nil
+			urls = append(urls, copied)
+		}
+	}
+	r.mu.RUnlock()
+
+	// 按 (排序字段, id) 比较，与数据库的键集分页一致；游标视为排序字段取游标值的记录
+	compare := func(a, b *models.URL) int {
+		var c int
+		switch query.Sort {
+		case SortAccessCount:
+			c = cmp.Compare(a.AccessCount, b.AccessCount)
+		case SortLastAccessAt:
+			c = a.LastAccessAt.Compare(b.LastAccessAt)
+		default:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if query.Desc {
+			c = -c
+		}
+		return c
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return compare(&urls[i], &urls[j]) < 0
+	})
+
+	var after *models.URL
+	if query.After != nil {
+		after = &models.URL{
+			ID:           query.After.ID,
+			AccessCount:  query.After.Count,
+			CreatedAt:    query.After.Time,
+			LastAccessAt: query.After.Time,
+		}
+	}
+	var page []models.URL
+	for i := range urls {
+		if after != nil && compare(&urls[i], after) <= 0 {
+			continue
+		}
+		if query.Limit > 0 && len(page) >= query.Limit {
+			break
+		}
+		page = append(page, urls[i])
+	}
+	return page, nil
+}
+
+// matchesQuery 链接是否满足查询的筛选条件
+func matchesQuery(url *models.URL, query URLQuery) bool {
+	switch {
+	case query.OwnerID != nil && url.OwnerID != *query.OwnerID,
+		query.Host != "" && url.DestinationHost != query.Host,
+		!query.CreatedAfter.IsZero() && url.CreatedAt.Before(query.CreatedAfter),
+		!query.CreatedBefore.IsZero() && !url.CreatedAt.Before(query.CreatedBefore),
+		!query.ExpiresAfter.IsZero() && url.ExpiresAt.Before(query.ExpiresAfter),
+		!query.ExpiresBefore.IsZero() && !url.ExpiresAt.Before(query.ExpiresBefore):
+		return false
+	}
+	if query.Tag != "" && !slices.Contains(url.Tags, query.Tag) {
+		return false
+	}
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, url.Status) {
+		return false
+	}
+	if query.Search != "" {
+		search := strings.ToLower(query.Search)
+		if !strings.Contains(strings.ToLower(url.OriginalURL), search) &&
+			!strings.Contains(strings.ToLower(url.Title), search) {
+			return false
+		}
+	}
+	return true
+}
+
+// Update 按 url.Version 乐观地更新目标URL、标题、短码、过期时间和状态，成功后 url.Version 加1
+func (r *MemoryURLRepository) Update(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var oldKey string
+	var stored *models.URL
+	for key, candidate := range r.urls {
+		if candidate.ID == url.ID {
+			oldKey, stored = key, candidate
+			break
+		}
+	}
+	if stored == nil || stored.Version != url.Version {
+		return ErrVersionConflict
+	}
+	newKey := r.key(url.ShortCode)
+	if _, exists := r.urls[newKey]; exists && newKey != oldKey {
+		return ErrDuplicateKey
+	}
+
+	url.URLHash = utils.HashURL(url.OriginalURL)
+	url.CanonicalHash = utils.HashURL(url.CanonicalURL)
+	url.DestinationHost = utils.URLHost(url.OriginalURL)
+	url.UpdatedAt = time.Now()
+	url.Version++
+	updated := *stored
+	updated.OriginalURL, updated.URLHash = url.OriginalURL, url.URLHash
+	updated.Title, updated.DestinationHost = url.Title, url.DestinationHost
+	updated.CanonicalURL, updated.CanonicalHash = url.CanonicalURL, url.CanonicalHash
+	updated.ShortCode, updated.CustomAlias = url.ShortCode, url.CustomAlias
+	updated.ExpiresAt, updated.ExpiresIn = url.ExpiresAt, url.ExpiresIn
+	updated.Status, updated.DeletedAt = url.Status, url.DeletedAt
+	updated.UpdatedAt, updated.Version = url.UpdatedAt, url.Version
+	delete(r.urls, oldKey)
+	r.urls[newKey] = &updated
+	return nil
+}
+
+// ExistingShortCodes 返回 shortCodes 中已被使用的短码
+func (r *MemoryURLRepository) ExistingShortCodes(shortCodes []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var existing []string
+	for _, code := range shortCodes {
+		if _, ok := r.urls[r.key(code)]; ok {
+			existing = append(existing, code)
+		}
+	}
+	return existing, nil
+}
+
+// IncrementAccess 增加访问计数并更新最后访问时间
+func (r *MemoryURLRepository) IncrementAccess(shortCode string, accessAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if url, ok := r.urls[r.key(shortCode)]; ok {
+		url.AccessCount++
+		url.LastAccessAt = accessAt
+	}
+	return nil
+}
+
+// DeleteExpired 删除在指定时间之前过期的记录，返回被删除的短码，已软删除的记录保留
+func (r *MemoryURLRepository) DeleteExpired(before time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shortCodes []string
+	for code, url := range r.urls {
+		if url.ExpiresAt.Before(before) && url.Status != models.URLStatusDeleted {
+			delete(r.urls, code)
+			shortCodes = append(shortCodes, code)
+		}
+	}
+	return shortCodes, nil
+}
+
+// EachShortCode 分批遍历已存储的短码（包括已过期但尚未删除的）
+func (r *MemoryURLRepository) EachShortCode(changedSince time.Time, batchSize int, fn func(shortCodes []string) error) error {
+	r.mu.RLock()
+	var shortCodes []string
+	for code, url := range r.urls {
+		if !url.UpdatedAt.Before(changedSince) {
+			shortCodes = append(shortCodes, code)
+		}
+	}
+	r.mu.RUnlock()
+
+	for start := 0; start < len(shortCodes); start += batchSize {
+		end := start + batchSize
+		if end > len(shortCodes) {
+			end = len(shortCodes)
+		}
+		if err := fn(shortCodes[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemorySequenceRepository 内存序列存储，用于测试
+type MemorySequenceRepository struct {
+	mu        sync.Mutex
+	sequences map[string]uint64 // name -> 下一个未分配的值
+}
+
+// NewMemorySequenceRepository 创建内存序列存储
+func NewMemorySequenceRepository() *MemorySequenceRepository {
+	return &MemorySequenceRepository{sequences: make(map[string]uint64)}
+}
+
+// NextBlock 预留 size 个连续值，返回第一个值
+func (r *MemorySequenceRepository) NextBlock(name string, size uint64) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, ok := r.sequences[name]
+	if !ok {
+		start = 1
+	}
+	r.sequences[name] = start + size
+	return start, nil
+}
+
+// MemoryKeyPoolRepository 内存短码池，用于测试
+type MemoryKeyPoolRepository struct {
+	mu        sync.Mutex
+	available []string
+	known     map[string]bool // 曾加入过池的短码
+}
+
+// NewMemoryKeyPoolRepository 创建内存短码池
+func NewMemoryKeyPoolRepository() *MemoryKeyPoolRepository {
+	return &MemoryKeyPoolRepository{known: make(map[string]bool)}
+}
+
+// Add 将短码加入池中，曾加入过的短码忽略
+func (r *MemoryKeyPoolRepository) Add(codes []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added := 0
+	for _, code := range codes {
+		if r.known[code] {
+			continue
+		}
+		r.known[code] = true
+		r.available = append(r.available, code)
+		added++
+	}
+	return added, nil
+}
+
+// Claim 领取最多 n 个短码
+func (r *MemoryKeyPoolRepository) Claim(n int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n > len(r.available) {
+		n = len(r.available)
+	}
+	codes := append([]string(nil), r.available[:n]...)
+	r.available = r.available[n:]
+	return codes, nil
+}
+
+// Available 池中未领取的短码个数
+func (r *MemoryKeyPoolRepository) Available() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.available)), nil
+}
+
+// MemoryStatsRepository 内存访问统计存储，用于测试
+type MemoryStatsRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	stats  []models.URLStats
+}
+
+// NewMemoryStatsRepository 创建内存访问统计存储
+func NewMemoryStatsRepository() *MemoryStatsRepository {
+	return &MemoryStatsRepository{}
+}
+
+// Create 记录一次访问
+func (r *MemoryStatsRepository) Create(stats *models.URLStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	stats.ID = r.nextID
+	r.stats = append(r.stats, *stats)
+	return nil
+}
+
+// DailyCounts 按天汇总指定时间之后的访问次数
+func (r *MemoryStatsRepository) DailyCounts(urlID uint, since time.Time) ([]DailyCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byDate := make(map[string]int64)
+	for _, stat := range r.stats {
+		if stat.URLID == urlID && stat.AccessAt.After(since) {
+			byDate[stat.AccessAt.Format("2006-01-02")]++
+		}
+	}
+
+	counts := make([]DailyCount, 0, len(byDate))
+	for date, count := range byDate {
+		counts = append(counts, DailyCount{Date: date, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Date > counts[j].Date
+	})
+	return counts, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+)
+
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errors.New("记录不存在")
+	// ErrDuplicateKey 违反唯一约束（如短码已存在）
+	ErrDuplicateKey = errors.New("记录已存在")
+	// ErrVersionConflict 乐观更新时记录已被其他请求修改
+	ErrVersionConflict = errors.New("记录版本冲突")
+)
+
+// DailyCount 每日访问计数
+type DailyCount struct {
+	Date  string
+	Count int64
+}
+
+// 链接列表的排序字段
+const (
+	SortCreatedAt    = "created_at"
+	SortAccessCount  = "access_count"
+	SortLastAccessAt = "last_access_at"
+)
+
+// URLQuery 链接列表查询条件，零值字段不参与筛选
+type URLQuery struct {
+	OwnerID       *string  // 创建者，为nil时不筛选（空字符串表示匿名创建）
+	Tag           string   // 标签
+	Host          string   // 目标URL的主机名（小写）
+	Statuses      []string // 状态
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+	Search        string     // 在原始URL和标题中按子串查找，忽略大小写
+	Sort          string     // 排序字段，默认 created_at
+	Desc          bool       // 是否降序
+	After         *URLCursor // 从游标之后开始，为nil时从第一条开始
+	Limit         int
+}
+
+// URLCursor 列表游标：上一页最后一条记录的排序字段值和ID，排序字段相同时按ID排序
+type URLCursor struct {
+	Time  time.Time // 按 created_at 或 last_access_at 排序时使用
+	Count int64     // 按 access_count 排序时使用
+	ID    uint
+}
+
+// URLRepository 短链接存储接口
+type URLRepository interface {
+	// FindByShortCode 根据短码查询
+	FindByShortCode(shortCode string) (*models.URL, error)
+	// FindReusable 查询同一创建者、规范化URL和有效期都相同，状态正常且过期时间晚于 minExpiresAt 的生成短码（非自定义别名）记录，
+	// 多条匹配时返回最新创建的一条
+	FindReusable(ownerID, canonicalURL string, expiresIn int64, minExpiresAt time.Time) (*models.URL, error)
+	// Create 创建短链接记录及其标签，未设置 CanonicalURL 时使用默认规则规范化；短码已存在时返回 ErrDuplicateKey
+	Create(url *models.URL) error
+	// Update 按 url.Version 乐观地更新目标URL、标题、短码、过期时间和状态（不包括标签），成功后 url.Version 加1；
+	// 版本不一致时返回 ErrVersionConflict，新短码已存在时返回 ErrDuplicateKey
+	Update(url *models.URL) error
+	// SetTags 替换链接的标签
+	SetTags(urlID uint, tags []string) error
+	// TagsOf 返回各链接的标签，按标签名排序
+	TagsOf(urlIDs []uint) (map[uint][]string, error)
+	// List 按条件、排序字段和游标查询链接列表，不填充标签
+	List(query URLQuery) ([]models.URL, error)
+	// ExistingShortCodes 返回 shortCodes 中已被使用的短码
+	ExistingShortCodes(shortCodes []string) ([]string, error)
+	// IncrementAccess 增加访问计数并更新最后访问时间
+	IncrementAccess(shortCode string, accessAt time.Time) error
+	// DeleteExpired 删除在指定时间之前过期的记录，返回被删除的短码；已软删除的记录保留，其短码不再分配
+	DeleteExpired(before time.Time) ([]string, error)
+	// EachShortCode 分批遍历 changedSince 之后创建或修改的短码（包括已过期但尚未删除的），changedSince 为零值时遍历全部
+	EachShortCode(changedSince time.Time, batchSize int, fn func(shortCodes []string) error) error
+}
+
+// SequenceRepository 序列存储接口，用于号段分配
+type SequenceRepository interface {
+	// NextBlock 原子地预留序列 name 的 size 个连续值，返回第一个值；序列不存在时从1开始
+	NextBlock(name string, size uint64) (uint64, error)
+}
+
+// KeyPoolRepository 预生成短码池存储接口
+type KeyPoolRepository interface {
+	// Add 将短码加入池中，已在池中的短码忽略，返回实际加入的个数
+	Add(codes []string) (int, error)
+	// Claim 原子地从池中领取最多 n 个未使用的短码，领取后不会再分配给其他实例
+	Claim(n int) ([]string, error)
+	// Available 池中未领取的短码个数
+	Available() (int64, error)
+}
+
+// StatsRepository 访问统计存储接口
+type StatsRepository interface {
+	// Create 记录一次访问
+	Create(stats *models.URLStats) error
+	// DailyCounts 按天汇总指定时间之后的访问次数，按日期倒序
+	DailyCounts(urlID uint, since time.Time) ([]DailyCount, error)
+}
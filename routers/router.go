@@ -1,6 +1,8 @@
 package routers
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/keenJoe/go-url-shortener/handlers"
 )
@@ -11,23 +13,52 @@ type Router interface {
 }
 
 // APIRouter API路由
-type APIRouter struct{}
+type APIRouter struct {
+	handler *handlers.Handler
+}
 
 // Register 注册API路由
 func (r *APIRouter) Register(engine *gin.Engine) {
 	api := engine.Group("/api")
 	{
-		api.POST("/shorten", handlers.CreateURL)
-		api.GET("/stats/:shortCode", handlers.GetURLStats)
+		api.POST("/shorten", r.handler.CreateURL)
+		api.GET("/links", r.handler.ListLinks)
+		api.GET("/links/:shortCode", r.handler.GetLink)
+		api.PATCH("/links/:shortCode", r.handler.UpdateLink)
+		api.DELETE("/links/:shortCode", r.handler.DeleteLink)
+		api.POST("/links/:shortCode/disable", r.handler.DisableLink)
+		api.POST("/links/:shortCode/enable", r.handler.EnableLink)
+		api.GET("/stats/:shortCode", r.handler.GetURLStats)
+		api.GET("/metrics", r.handler.GetMetrics)
 	}
 
+	// 健康检查
+	engine.GET("/healthz", r.handler.Healthz)
+	engine.GET("/readyz", r.handler.Readyz)
+
 	// 重定向路由
-	engine.GET("/:shortCode", handlers.RedirectURL)
+	engine.GET("/:shortCode", r.handler.RedirectURL)
 }
 
 // InitRouter 初始化路由
-func InitRouter() Router {
-	return &APIRouter{}
+func InitRouter(handler *handlers.Handler) Router {
+	return &APIRouter{handler: handler}
+}
+
+// ReservedPrefixes 返回引擎上已注册路由的首段路径（如 api、healthz），
+// 这些词用作短码时会被对应路由遮蔽，不能作为自定义别名
+func ReservedPrefixes(engine *gin.Engine) []string {
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, route := range engine.Routes() {
+		segment := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]
+		if segment == "" || segment[0] == ':' || segment[0] == '*' || seen[segment] {
+			continue
+		}
+		seen[segment] = true
+		prefixes = append(prefixes, segment)
+	}
+	return prefixes
 }
 
 // RegisterMiddleware 注册中间件
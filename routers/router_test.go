@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/keenJoe/go-url-shortener/handlers"
+)
+
+func TestReservedPrefixes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	InitRouter(handlers.NewHandler(nil, nil, nil, nil, nil)).Register(engine)
+
+	got := ReservedPrefixes(engine)
+	slices.Sort(got)
+	// 重复的首段只出现一次，重定向路由的参数段不算保留词
+	want := []string{"api", "healthz", "readyz"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReservedPrefixes() = %v, want %v", got, want)
+	}
+}
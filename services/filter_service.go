@@ -0,0 +1,230 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/repository"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// snapshotSafetyMargin 从快照恢复后回补的时间余量：短码先写库再加入过滤器，
+// 快照时刻可能存在已写库但尚未加入过滤器的短码
+const snapshotSafetyMargin = 5 * time.Minute
+
+// snapshotter 支持快照保存与恢复的过滤器（进程内过滤器）
+type snapshotter interface {
+	WriteSnapshot(w io.Writer, completeAt time.Time) error
+	RestoreSnapshot(r io.Reader) (time.Time, error)
+}
+
+// sharedFilter 跨实例共享的过滤器，已有实例完成全量预热时无需重复预热
+type sharedFilter interface {
+	Warmed() (bool, error)
+	MarkWarmed() error
+}
+
+// batchAdder 支持批量添加的过滤器，预热时减少网络往返；写入失败时返回错误
+type batchAdder interface {
+	AddAll(items []string) error
+}
+
+// remover 支持删除元素的过滤器（计数布隆过滤器）
+type remover interface {
+	Remove(item string)
+}
+
+// FilterService 维护短码布隆过滤器：启动预热、快照保存与恢复
+type FilterService struct {
+	urls      repository.URLRepository
+	filter    utils.Filter
+	store     utils.SnapshotStore // 为nil或过滤器不支持快照时不使用快照
+	batchSize int
+	// caseInsensitive 大小写不敏感模式下过滤器中保存小写短码
+	caseInsensitive bool
+
+	// ready 预热完成前过滤器不完整，不能用它判断短码不存在
+	ready atomic.Bool
+	// completeAt 过滤器包含全部已存储短码的时间点，即预热开始时间。此后其他实例创建的短码不会加入本实例的过滤器，
+	// 因此快照只记录这个时间点，而不是保存快照的时间
+	completeAt time.Time
+	saveMu     sync.Mutex
+}
+
+// NewFilterService 创建过滤器服务，store 为nil时每次启动都从存储全量预热；
+// caseInsensitive 为true时短码统一转为小写后再加入和查询。
+// 支持删除的过滤器不使用快照：回补时无法区分快照中已有的短码，重复计数后单次删除无法清零
+func NewFilterService(urls repository.URLRepository, filter utils.Filter, store utils.SnapshotStore, batchSize int,
+	caseInsensitive bool) *FilterService {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	if _, ok := filter.(snapshotter); !ok {
+		store = nil
+	}
+	if _, ok := filter.(remover); ok {
+		store = nil
+	}
+	return &FilterService{
+		urls:            urls,
+		filter:          filter,
+		store:           store,
+		batchSize:       batchSize,
+		caseInsensitive: caseInsensitive,
+	}
+}
+
+// Add 将短码加入过滤器
+func (f *FilterService) Add(shortCode string) {
+	f.filter.Add(f.key(shortCode))
+}
+
+// Remove 从过滤器中删除短码，过滤器不支持删除时忽略。
+// 只能删除确实加入过的短码，调用方需保证短码已从存储中删除
+func (f *FilterService) Remove(shortCode string) {
+	if r, ok := f.filter.(remover); ok {
+		r.Remove(f.key(shortCode))
+	}
+}
+
+// MightContain 短码是否可能存在，预热完成前始终返回true
+func (f *FilterService) MightContain(shortCode string) bool {
+	return !f.ready.Load() || f.filter.Contains(f.key(shortCode))
+}
+
+// key 返回短码在过滤器中的形式
+func (f *FilterService) key(shortCode string) string {
+	if f.caseInsensitive {
+		return strings.ToLower(shortCode)
+	}
+	return shortCode
+}
+
+// Ready 过滤器是否已预热完成
+func (f *FilterService) Ready() bool {
+	return f.ready.Load()
+}
+
+// Stats 返回过滤器统计
+func (f *FilterService) Stats() utils.BloomFilterStats {
+	return f.filter.Stats()
+}
+
+// WarmUp 预热过滤器：共享过滤器已被预热时直接就绪；否则优先从快照恢复并回补快照完整时间点之后创建或修改的短码，
+// 没有可用快照时从存储全量加载。任何一批写入失败时返回错误，不标记预热完成，由调用方重试
+func (f *FilterService) WarmUp() error {
+	start := time.Now()
+
+	shared, isShared := f.filter.(sharedFilter)
+	if isShared {
+		warmed, err := shared.Warmed()
+		if err != nil {
+			return err
+		}
+		if warmed {
+			f.ready.Store(true)
+			log.Printf("共享布隆过滤器已由其他实例预热")
+			return nil
+		}
+	}
+
+	var since time.Time
+	if completeAt, err := f.restore(); err == nil {
+		since = completeAt.Add(-snapshotSafetyMargin)
+		log.Printf("已从快照恢复布隆过滤器，快照完整时间 %s", completeAt.Format(time.RFC3339))
+	} else if !errors.Is(err, utils.ErrSnapshotNotFound) {
+		log.Printf("恢复布隆过滤器快照失败，将全量预热: %v", err)
+	}
+
+	total := 0
+	err := f.urls.EachShortCode(since, f.batchSize, func(shortCodes []string) error {
+		for i, shortCode := range shortCodes {
+			shortCodes[i] = f.key(shortCode)
+		}
+		if adder, ok := f.filter.(batchAdder); ok {
+			if err := adder.AddAll(shortCodes); err != nil {
+				return fmt.Errorf("写入过滤器失败: %v", err)
+			}
+		} else {
+			for _, shortCode := range shortCodes {
+				f.filter.Add(shortCode)
+			}
+		}
+		total += len(shortCodes)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if isShared {
+		if err := shared.MarkWarmed(); err != nil {
+			return err
+		}
+	}
+
+	f.completeAt = start
+	f.ready.Store(true)
+	log.Printf("布隆过滤器预热完成: 加载 %d 个短码，耗时 %s", total, time.Since(start))
+	return nil
+}
+
+// SaveSnapshot 保存过滤器快照，未配置快照存储或预热未完成时跳过（不完整的快照会导致恢复后漏判）
+func (f *FilterService) SaveSnapshot() error {
+	if f.store == nil || !f.ready.Load() {
+		return nil
+	}
+
+	f.saveMu.Lock()
+	defer f.saveMu.Unlock()
+
+	var buf bytes.Buffer
+	if err := f.filter.(snapshotter).WriteSnapshot(&buf, f.completeAt); err != nil {
+		return err
+	}
+	return f.store.Save(buf.Bytes())
+}
+
+// RunSnapshotLoop 定期保存快照，直到 stop 关闭
+func (f *FilterService) RunSnapshotLoop(interval time.Duration, stop <-chan struct{}) {
+	if f.store == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.SaveSnapshot(); err != nil {
+				log.Printf("保存布隆过滤器快照失败: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// restore 从快照存储恢复过滤器，返回快照完整的时间点
+func (f *FilterService) restore() (time.Time, error) {
+	if f.store == nil {
+		return time.Time{}, utils.ErrSnapshotNotFound
+	}
+
+	data, err := f.store.Load()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return f.filter.(snapshotter).RestoreSnapshot(bytes.NewReader(data))
+}
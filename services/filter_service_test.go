@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// memorySnapshotStore 内存快照存储
+type memorySnapshotStore struct {
+	data []byte
+}
+
+func (s *memorySnapshotStore) Save(data []byte) error {
+	s.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memorySnapshotStore) Load() ([]byte, error) {
+	if s.data == nil {
+		return nil, utils.ErrSnapshotNotFound
+	}
+	return s.data, nil
+}
+
+func TestFilterServiceSnapshotRecordsWarmUpTime(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	store := &memorySnapshotStore{}
+	filter := NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), store, 0, false)
+
+	beforeWarmUp := time.Now()
+	if err := filter.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	afterWarmUp := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := filter.SaveSnapshot(); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	// 其他实例创建的短码不在本实例的过滤器中，快照时间只能是预热时间
+	completeAt, err := utils.NewBloomFilter(1000, 0.01).RestoreSnapshot(bytes.NewReader(store.data))
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if completeAt.Before(beforeWarmUp) || completeAt.After(afterWarmUp) {
+		t.Errorf("snapshot time = %v, want warm-up time in [%v, %v]", completeAt, beforeWarmUp, afterWarmUp)
+	}
+}
+
+func TestFilterServiceRestoreTopsUp(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	store := &memorySnapshotStore{}
+	first := NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), store, 0, false)
+	if err := first.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if err := first.SaveSnapshot(); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	// 快照之后由其他实例创建的短码
+	now := time.Now()
+	if err := urls.Create(&models.URL{OriginalURL: "https://example.com", ShortCode: "later01", CreatedAt: now}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	second := NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), store, 0, false)
+	if err := second.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if !second.MightContain("later01") {
+		t.Errorf("short code created after snapshot missing after restore")
+	}
+}
+
+// flakySharedFilter 共享过滤器替身，failAdds 为true时批量写入失败
+type flakySharedFilter struct {
+	*utils.BloomFilter
+	failAdds bool
+	warmed   bool
+}
+
+func (f *flakySharedFilter) AddAll(items []string) error {
+	if f.failAdds {
+		return errors.New("redis unavailable")
+	}
+	for _, item := range items {
+		f.BloomFilter.Add(item)
+	}
+	return nil
+}
+
+func (f *flakySharedFilter) Warmed() (bool, error) { return f.warmed, nil }
+
+func (f *flakySharedFilter) MarkWarmed() error {
+	f.warmed = true
+	return nil
+}
+
+func TestFilterServiceWarmUpFailedAdd(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	url := &models.URL{OriginalURL: "https://example.com/", ShortCode: "abcdefg", CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour)}
+	if err := urls.Create(url); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	shared := &flakySharedFilter{BloomFilter: utils.NewBloomFilter(1000, 0.01), failAdds: true}
+	filter := NewFilterService(urls, shared, nil, 0, false)
+
+	// 写入失败时不标记预热完成，也不就绪
+	if err := filter.WarmUp(); err == nil {
+		t.Fatalf("WarmUp() error = nil, want write failure")
+	}
+	if shared.warmed || filter.Ready() {
+		t.Errorf("warmed = %v, Ready() = %v after failed warm-up, want false", shared.warmed, filter.Ready())
+	}
+	if !filter.MightContain("zzzzzzz") {
+		t.Errorf("MightContain() = false before warm-up completed")
+	}
+
+	// 重试成功后标记完成
+	shared.failAdds = false
+	if err := filter.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() retry error = %v", err)
+	}
+	if !shared.warmed || !filter.Ready() {
+		t.Errorf("warmed = %v, Ready() = %v after retry, want true", shared.warmed, filter.Ready())
+	}
+	if !filter.MightContain(url.ShortCode) {
+		t.Errorf("MightContain(%q) = false after warm-up", url.ShortCode)
+	}
+}
+
+func TestFilterServiceCountingRemoveAfterRestart(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	if err := urls.Create(&models.URL{OriginalURL: "https://example.com", ShortCode: "abcdefg", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// 上次运行留下的快照已包含该短码，而它也在回补的时间范围内
+	previous := utils.NewCountingBloomFilter(1000, 0.01)
+	previous.Add("abcdefg")
+	var buf bytes.Buffer
+	if err := previous.WriteSnapshot(&buf, time.Now()); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	store := &memorySnapshotStore{data: buf.Bytes()}
+
+	filter := NewFilterService(urls, utils.NewCountingBloomFilter(1000, 0.01), store, 0, false)
+	if err := filter.WarmUp(); err != nil {
+		t.Fatalf("WarmUp() error = %v", err)
+	}
+	if !filter.MightContain("abcdefg") {
+		t.Fatalf("MightContain() = false after warm-up")
+	}
+	filter.Remove("abcdefg")
+	if filter.MightContain("abcdefg") {
+		t.Errorf("MightContain() = true after single Remove, short code counted twice")
+	}
+
+	// 计数过滤器不保存快照
+	store.data = nil
+	if err := filter.SaveSnapshot(); err != nil || store.data != nil {
+		t.Errorf("SaveSnapshot() = %v, saved %d bytes, want no snapshot", err, len(store.data))
+	}
+}
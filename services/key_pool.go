@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/repository"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// ErrKeyPoolEmpty 短码池为空且无法补充
+var ErrKeyPoolEmpty = errors.New("短码池已耗尽")
+
+// KeyPool 预生成短码池：后台生成未使用的短码放入共享池（数据库表或Redis集合），
+// 各实例按批原子地领取到本地缓冲后逐个分配，不同请求不会拿到同一个短码
+type KeyPool struct {
+	urls      repository.URLRepository
+	store     repository.KeyPoolRepository
+	generator utils.ShortCodeGenerator // 生成补充到池中的短码
+	batchSize int
+	size      int64 // 池的目标容量
+
+	mu     sync.Mutex
+	buffer []string // 本实例已领取、尚未分配的短码
+}
+
+// NewKeyPool 创建短码池，generator 生成补充到池中的短码，batchSize 为每次领取的个数（默认100），
+// size 为池的目标容量（默认100000）
+func NewKeyPool(urls repository.URLRepository, store repository.KeyPoolRepository, generator utils.ShortCodeGenerator,
+	batchSize int, size int64) *KeyPool {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if size <= 0 {
+		size = 100000
+	}
+	return &KeyPool{urls: urls, store: store, generator: generator, batchSize: batchSize, size: size}
+}
+
+// Generate 从池中分配一个短码，本地缓冲用完时从池中领取下一批，池为空时先同步补充一批
+func (p *KeyPool) Generate(string, int) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buffer) == 0 {
+		codes, err := p.store.Claim(p.batchSize)
+		if err != nil {
+			return "", err
+		}
+		if len(codes) == 0 {
+			if _, err := p.fill(p.batchSize); err != nil {
+				return "", err
+			}
+			if codes, err = p.store.Claim(p.batchSize); err != nil {
+				return "", err
+			}
+		}
+		if len(codes) == 0 {
+			return "", ErrKeyPoolEmpty
+		}
+		p.buffer = codes
+	}
+
+	code := p.buffer[len(p.buffer)-1]
+	p.buffer = p.buffer[:len(p.buffer)-1]
+	return code, nil
+}
+
+// Refill 池中剩余不足目标容量一半时补充到目标容量，返回加入的短码个数
+func (p *KeyPool) Refill() (int, error) {
+	available, err := p.store.Available()
+	if err != nil {
+		return 0, err
+	}
+	if available >= p.size/2 {
+		return 0, nil
+	}
+	return p.fill(int(p.size - available))
+}
+
+// RunRefillLoop 定期补充短码池，直到 stop 关闭
+func (p *KeyPool) RunRefillLoop(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if added, err := p.Refill(); err != nil {
+			log.Printf("补充短码池失败: %v", err)
+		} else if added > 0 {
+			log.Printf("短码池已补充 %d 个短码", added)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// fill 生成 n 个未被使用的短码加入池中，已被使用或已在池中的短码跳过
+func (p *KeyPool) fill(n int) (int, error) {
+	const batchSize = 1000
+
+	total := 0
+	// 限制生成轮数，短码空间接近耗尽时不会无限循环
+	for round := 0; total < n && round < n/batchSize+10; round++ {
+		count := n - total
+		if count > batchSize {
+			count = batchSize
+		}
+
+		candidates := make(map[string]bool, count)
+		for i := 0; i < 2*count && len(candidates) < count; i++ {
+			code, err := p.generator.Generate("", 0)
+			if err != nil {
+				return total, err
+			}
+			candidates[code] = true
+		}
+		codes := make([]string, 0, count)
+		for code := range candidates {
+			codes = append(codes, code)
+		}
+
+		existing, err := p.urls.ExistingShortCodes(codes)
+		if err != nil {
+			return total, err
+		}
+		for _, code := range existing {
+			delete(candidates, code)
+		}
+		codes = codes[:0]
+		for code := range candidates {
+			codes = append(codes, code)
+		}
+
+		added, err := p.store.Add(codes)
+		if err != nil {
+			return total, err
+		}
+		total += added
+	}
+	return total, nil
+}
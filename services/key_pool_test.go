@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
+)
+
+// sequenceGenerator 按顺序生成 k000000、k000001…… 的短码
+type sequenceGenerator struct {
+	mu   sync.Mutex
+	next int
+}
+
+func (g *sequenceGenerator) Generate(string, int) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	code := fmt.Sprintf("k%06d", g.next)
+	g.next++
+	return code, nil
+}
+
+func TestKeyPoolRefillSkipsUsedCodes(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	for _, code := range []string{"k000001", "k000003"} {
+		if err := urls.Create(&models.URL{OriginalURL: "https://example.com", ShortCode: code, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	store := repository.NewMemoryKeyPoolRepository()
+	pool := NewKeyPool(urls, store, &sequenceGenerator{}, 10, 10)
+
+	// 跳过已被使用的短码后继续生成，直到补满目标容量
+	added, err := pool.Refill()
+	if err != nil || added != 10 {
+		t.Fatalf("Refill() = %d, %v, want 10", added, err)
+	}
+	codes, _ := store.Claim(100)
+	if len(codes) != 10 {
+		t.Errorf("pool holds %d codes, want 10", len(codes))
+	}
+	for _, code := range codes {
+		if code == "k000001" || code == "k000003" {
+			t.Errorf("pool contains used code %q", code)
+		}
+	}
+
+	// 剩余不少于目标容量一半时不补充
+	if _, err := store.Add([]string{"x1", "x2", "x3", "x4", "x5"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if added, err := pool.Refill(); err != nil || added != 0 {
+		t.Errorf("Refill() of half-full pool = %d, %v, want 0", added, err)
+	}
+}
+
+func TestKeyPoolGenerateRefillsWhenEmpty(t *testing.T) {
+	urls := repository.NewMemoryURLRepository()
+	pool := NewKeyPool(urls, repository.NewMemoryKeyPoolRepository(), &sequenceGenerator{}, 5, 100)
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+		wg   sync.WaitGroup
+	)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				code, err := pool.Generate("", 0)
+				if err != nil {
+					t.Errorf("Generate() error = %v", err)
+					return
+				}
+				mu.Lock()
+				if seen[code] {
+					t.Errorf("Generate() returned %q twice", code)
+				}
+				seen[code] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 80 {
+		t.Errorf("generated %d distinct codes, want 80", len(seen))
+	}
+}
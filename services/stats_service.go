@@ -3,8 +3,8 @@ package services
 import (
 	"time"
 
-	"github.com/keenJoe/go-url-shortener/database"
 	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
 )
 
 // URLStatsData 统计数据结构
@@ -20,32 +20,33 @@ type DailyStat struct {
 	Count int64  `json:"count"`
 }
 
+// StatsService 访问统计服务
+type StatsService struct {
+	urls  repository.URLRepository
+	stats repository.StatsRepository
+}
+
+// NewStatsService 创建访问统计服务
+func NewStatsService(urls repository.URLRepository, stats repository.StatsRepository) *StatsService {
+	return &StatsService{urls: urls, stats: stats}
+}
+
 // GetURLStats 获取URL访问统计
-func GetURLStats(shortCode string) (*URLStatsData, error) {
-	var url models.URL
-	if err := database.DB.Where("short_code = ?", shortCode).First(&url).Error; err != nil {
+func (s *StatsService) GetURLStats(shortCode string) (*URLStatsData, error) {
+	url, err := s.urls.FindByShortCode(shortCode)
+	if err != nil {
 		return nil, err
 	}
 
 	// 获取过去30天的每日统计
-	var dailyStats []DailyStat
-	rows, err := database.DB.Raw(`
-		SELECT DATE(access_at) as date, COUNT(*) as count 
-		FROM url_stats 
-		WHERE url_id = ? AND access_at > DATE_SUB(NOW(), INTERVAL 30 DAY)
-		GROUP BY DATE(access_at)
-		ORDER BY date DESC
-	`, url.ID).Rows()
-
+	counts, err := s.stats.DailyCounts(url.ID, time.Now().AddDate(0, 0, -30))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var stat DailyStat
-		rows.Scan(&stat.Date, &stat.Count)
-		dailyStats = append(dailyStats, stat)
+	var dailyStats []DailyStat
+	for _, count := range counts {
+		dailyStats = append(dailyStats, DailyStat{Date: count.Date, Count: count.Count})
 	}
 
 	return &URLStatsData{
@@ -56,13 +57,18 @@ func GetURLStats(shortCode string) (*URLStatsData, error) {
 }
 
 // RecordURLAccess 记录URL访问
-func RecordURLAccess(urlID uint, ip, userAgent, referer string) error {
+func (s *StatsService) RecordURLAccess(shortCode, ip, userAgent, referer string) error {
+	url, err := s.urls.FindByShortCode(shortCode)
+	if err != nil {
+		return err
+	}
+
 	stats := models.URLStats{
-		URLID:     urlID,
+		URLID:     url.ID,
 		AccessIP:  ip,
 		UserAgent: userAgent,
 		Referer:   referer,
 		AccessAt:  time.Now(),
 	}
-	return database.DB.Create(&stats).Error
+	return s.stats.Create(&stats)
 }
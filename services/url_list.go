@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
+)
+
+var (
+	// ErrInvalidQuery 列表查询参数不合法
+	ErrInvalidQuery = errors.New("查询参数不合法")
+	// ErrInvalidCursor 游标无效或与当前排序方式不一致
+	ErrInvalidCursor = errors.New("游标无效")
+)
+
+const (
+	// defaultListLimit 每页默认条数，maxListLimit 每页最多条数
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListOptions 链接列表的筛选、排序和分页选项，零值字段不参与筛选
+type ListOptions struct {
+	// OwnerID 创建者，为nil时不筛选
+	OwnerID *string
+	// Tag 标签，Domain 目标URL的域名（精确匹配主机名）
+	Tag    string
+	Domain string
+	// Statuses 状态，为空时返回除已删除外的全部链接
+	Statuses []string
+	// 创建时间和过期时间范围，包含起点不包含终点
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+	// Search 在原始URL和标题中按子串查找，忽略大小写
+	Search string
+	// Sort 排序字段：created_at（默认）、access_count 或 last_access_at；Asc 为true时升序，默认降序
+	Sort string
+	Asc  bool
+	// Cursor 上一页返回的游标，为空时从第一页开始
+	Cursor string
+	// Limit 每页条数，默认20，最多100
+	Limit int
+}
+
+// listCursor 游标内容，带上排序方式以拒绝在不同排序之间复用游标
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Asc   bool      `json:"a,omitempty"`
+	Time  time.Time `json:"t,omitempty"`
+	Count int64     `json:"c,omitempty"`
+	ID    uint      `json:"i"`
+}
+
+// ListShortURLs 按条件分页查询链接及其标签，返回本页链接和下一页的游标，没有下一页时游标为空
+func (s *URLService) ListShortURLs(opts ListOptions) ([]models.URL, string, error) {
+	query, err := s.listQuery(opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 多查一条判断是否还有下一页
+	limit := query.Limit
+	query.Limit++
+	urls, err := s.urls.List(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(urls) > limit {
+		urls = urls[:limit]
+		last := urls[limit-1]
+		next = encodeCursor(listCursor{
+			Sort:  query.Sort,
+			Asc:   opts.Asc,
+			Time:  sortTime(&last, query.Sort),
+			Count: last.AccessCount,
+			ID:    last.ID,
+		})
+	}
+
+	ids := make([]uint, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	tags, err := s.urls.TagsOf(ids)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range urls {
+		urls[i].Tags = tags[urls[i].ID]
+	}
+	return urls, next, nil
+}
+
+// listQuery 校验选项并转换为存储层的查询条件
+func (s *URLService) listQuery(opts ListOptions) (repository.URLQuery, error) {
+	query := repository.URLQuery{
+		OwnerID:       opts.OwnerID,
+		Tag:           strings.ToLower(strings.TrimSpace(opts.Tag)),
+		Host:          strings.TrimSuffix(strings.ToLower(strings.TrimSpace(opts.Domain)), "."),
+		Statuses:      opts.Statuses,
+		CreatedAfter:  opts.CreatedAfter,
+		CreatedBefore: opts.CreatedBefore,
+		ExpiresAfter:  opts.ExpiresAfter,
+		ExpiresBefore: opts.ExpiresBefore,
+		Search:        opts.Search,
+		Sort:          opts.Sort,
+		Desc:          !opts.Asc,
+		Limit:         opts.Limit,
+	}
+
+	if query.Sort == "" {
+		query.Sort = repository.SortCreatedAt
+	}
+	switch query.Sort {
+	case repository.SortCreatedAt, repository.SortAccessCount, repository.SortLastAccessAt:
+	default:
+		return query, ErrInvalidQuery
+	}
+
+	if len(query.Statuses) == 0 {
+		query.Statuses = []string{models.URLStatusActive, models.URLStatusDisabled}
+	}
+	for _, status := range query.Statuses {
+		if !validStatus(status) {
+			return query, ErrInvalidQuery
+		}
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultListLimit
+	}
+	if query.Limit > maxListLimit {
+		query.Limit = maxListLimit
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil || cursor.Sort != query.Sort || cursor.Asc != opts.Asc {
+			return query, ErrInvalidCursor
+		}
+		query.After = &repository.URLCursor{Time: cursor.Time, Count: cursor.Count, ID: cursor.ID}
+	}
+	return query, nil
+}
+
+// sortTime 返回按时间排序时记录的排序字段值
+func sortTime(url *models.URL, sortBy string) time.Time {
+	if sortBy == repository.SortLastAccessAt {
+		return url.LastAccessAt
+	}
+	return url.CreatedAt
+}
+
+// encodeCursor 将游标编码为URL安全的字符串
+func encodeCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor 解码游标
+func decodeCursor(s string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
+
+// validStatus 是否为合法的链接状态
+func validStatus(status string) bool {
+	return slices.Contains([]string{models.URLStatusActive, models.URLStatusDisabled, models.URLStatusDeleted}, status)
+}
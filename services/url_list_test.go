@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
+)
+
+// seedLinks 创建 n 条 alice 的链接，创建时间依次递增，另有一条 bob 的链接和一条 alice 已删除的链接。
+// 排序值相同等翻页边界情况由 repository 包的 TestGormListPaging 覆盖
+func seedLinks(t *testing.T, urls repository.URLRepository, n int) {
+	t.Helper()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	links := []*models.URL{
+		{OriginalURL: "https://example.com/bob", OwnerID: "bob", ShortCode: "bob", CreatedAt: base},
+		{OriginalURL: "https://example.com/gone", OwnerID: "alice", ShortCode: "gone", CreatedAt: base, Status: models.URLStatusDeleted},
+	}
+	for i := 0; i < n; i++ {
+		links = append(links, &models.URL{
+			OriginalURL: "https://example.com/" + strconv.Itoa(i),
+			OwnerID:     "alice",
+			ShortCode:   "link" + strconv.Itoa(i),
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	for _, url := range links {
+		url.ExpiresAt = base.AddDate(100, 0, 0)
+		if err := urls.Create(url); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+}
+
+func TestListShortURLsPaging(t *testing.T) {
+	const total = 7
+	s, urls := newTestService(t, URLServiceOptions{})
+	seedLinks(t, urls, total)
+	owner := "alice"
+
+	for _, asc := range []bool{false, true} {
+		t.Run("asc="+strconv.FormatBool(asc), func(t *testing.T) {
+			var codes []string
+			opts := ListOptions{OwnerID: &owner, Asc: asc, Limit: 3}
+			for pages := 0; ; pages++ {
+				if pages > total {
+					t.Fatalf("paging did not terminate")
+				}
+				page, next, err := s.ListShortURLs(opts)
+				if err != nil {
+					t.Fatalf("ListShortURLs() error = %v", err)
+				}
+				for _, url := range page {
+					codes = append(codes, url.ShortCode)
+				}
+				if next == "" {
+					break
+				}
+				if len(page) != opts.Limit {
+					t.Errorf("page size = %d, want %d", len(page), opts.Limit)
+				}
+				opts.Cursor = next
+			}
+
+			want := make([]string, total)
+			for i := range want {
+				want[i] = "link" + strconv.Itoa(i)
+				if !asc {
+					want[i] = "link" + strconv.Itoa(total-1-i)
+				}
+			}
+			if !slices.Equal(codes, want) {
+				t.Errorf("listed %v, want %v", codes, want)
+			}
+		})
+	}
+}
+
+func TestListShortURLsInvalid(t *testing.T) {
+	s, urls := newTestService(t, URLServiceOptions{})
+	seedLinks(t, urls, 5)
+	_, next, err := s.ListShortURLs(ListOptions{Limit: 2})
+	if err != nil || next == "" {
+		t.Fatalf("ListShortURLs() = %q, %v", next, err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    ListOptions
+		wantErr error
+	}{
+		{name: "cursor with other sort", opts: ListOptions{Cursor: next, Sort: repository.SortAccessCount}, wantErr: ErrInvalidCursor},
+		{name: "cursor with other order", opts: ListOptions{Cursor: next, Asc: true}, wantErr: ErrInvalidCursor},
+		{name: "malformed cursor", opts: ListOptions{Cursor: "not-a-cursor"}, wantErr: ErrInvalidCursor},
+		{name: "unknown sort", opts: ListOptions{Sort: "title"}, wantErr: ErrInvalidQuery},
+		{name: "unknown status", opts: ListOptions{Statuses: []string{"archived"}}, wantErr: ErrInvalidQuery},
+		{name: "same sort", opts: ListOptions{Cursor: next}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.ListShortURLs(tt.opts); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ListShortURLs() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
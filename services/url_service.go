@@ -2,154 +2,653 @@ package services
 
 import (
 	"errors"
+	"log"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/keenJoe/go-url-shortener/cache"
-	"github.com/keenJoe/go-url-shortener/database"
 	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
 	"github.com/keenJoe/go-url-shortener/utils"
-	"gorm.io/gorm"
+	"golang.org/x/sync/singleflight"
 )
 
-// CreateShortURL 创建短链接
-func CreateShortURL(originalURL string, customAlias string, expiration time.Duration) (string, error) {
-	// 检查URL是否已存在
-	var existingURL models.URL
-	result := database.DB.Where("original_url = ?", originalURL).First(&existingURL)
-	if result.Error == nil {
-		// URL已存在，检查是否过期
-		if existingURL.ExpiresAt.After(time.Now()) {
-			return existingURL.ShortCode, nil
-		}
+var (
+	// ErrInvalidShortCode 短码不合法
+	ErrInvalidShortCode = errors.New("短码不合法")
+	// ErrNotFound 短码不存在
+	ErrNotFound = errors.New("短码不存在")
+	// ErrExpired 链接已过期
+	ErrExpired = errors.New("链接已过期")
+	// ErrDisabled 链接已停用
+	ErrDisabled = errors.New("链接已停用")
+	// ErrDeleted 链接已删除
+	ErrDeleted = errors.New("链接已删除")
+	// ErrInvalidAlias 自定义别名不符合长度或字符规则
+	ErrInvalidAlias = errors.New("自定义别名不合法")
+	// ErrAliasReserved 自定义别名是保留词
+	ErrAliasReserved = errors.New("自定义别名是保留词")
+	// ErrAliasBlocked 自定义别名包含屏蔽词
+	ErrAliasBlocked = errors.New("自定义别名包含屏蔽词")
+	// ErrAliasTaken 自定义别名已被使用
+	ErrAliasTaken = errors.New("自定义别名已被使用")
+	// ErrForbidden 请求者不是链接的创建者
+	ErrForbidden = errors.New("无权访问该链接")
+	// ErrVersionConflict 链接已被其他请求修改
+	ErrVersionConflict = errors.New("链接已被修改")
+	// ErrInvalidTitle 标题过长
+	ErrInvalidTitle = errors.New("标题不合法")
+	// ErrInvalidTags 标签为空、过长或数量过多
+	ErrInvalidTags = errors.New("标签不合法")
+)
+
+const (
+	// maxGenerateAttempts 生成短码的最多尝试次数，包括跳过保留词、屏蔽词和短码冲突；
+	// 确定性生成器用完这些次数后再以同样的次数尝试随机短码
+	maxGenerateAttempts = 10
+	// reuseTolerance 复用已有链接时，剩余有效期允许比请求的有效期短的时长
+	reuseTolerance = time.Minute
+	// maxTitleLength 标题的最大字符数
+	maxTitleLength = 255
+	// maxTagLength 单个标签的最大字符数，maxTags 每个链接的最多标签数
+	maxTagLength = 64
+	maxTags      = 10
+)
+
+// deterministicGenerator 同一URL总是生成相同短码序列的生成器
+type deterministicGenerator interface {
+	Deterministic() bool
+}
+
+// URLServiceOptions 短链接服务选项
+type URLServiceOptions struct {
+	// NegativeTTL 不存在或已过期短码的负缓存时间，默认1分钟
+	NegativeTTL time.Duration
+	// Generator 短码生成策略，默认随机生成7位base62短码
+	Generator utils.ShortCodeGenerator
+	// CodeRules 生成短码的校验规则，AliasRules 自定义别名的校验规则，零值时使用默认规则
+	CodeRules  utils.CodeRules
+	AliasRules utils.CodeRules
+	// Policy 保留词与屏蔽词策略，同时作用于自定义别名和生成短码，为nil时不限制
+	Policy *utils.CodePolicy
+	// Normalizer URL规范化规则，规范形式相同的URL复用同一短码，为nil时使用默认规则
+	Normalizer *utils.URLNormalizer
+	// CaseInsensitive 大小写不敏感模式：自定义别名按小写保存，访问时短码先转为小写再查询缓存和过滤器，
+	// 生成器应使用单一大小写的字符集，存储层需同时开启大小写不敏感查询
+	CaseInsensitive bool
+}
+
+// URLService 短链接服务
+type URLService struct {
+	urls   repository.URLRepository
+	cache  cache.Cache
+	filter *FilterService
+	// urlFilter 已创建过短链接的规范化URL
+	urlFilter utils.Filter
+	// fallback 确定性生成器的短码序列都已被占用时使用的随机生成器
+	fallback utils.ShortCodeGenerator
+	opts     URLServiceOptions
+	// loads 合并同一短码并发的回源查询，避免缓存失效时击穿数据库
+	loads singleflight.Group
+}
+
+// NewURLService 创建短链接服务，filter 为短码过滤器，urlFilter 为规范化URL过滤器
+func NewURLService(urls repository.URLRepository, urlCache cache.Cache, filter *FilterService, urlFilter utils.Filter,
+	opts URLServiceOptions) *URLService {
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = time.Minute
+	}
+	if opts.CodeRules.Alphabet == nil {
+		opts.CodeRules = utils.DefaultCodeRules()
+	}
+	if opts.AliasRules.Alphabet == nil {
+		opts.AliasRules = utils.DefaultAliasRules()
+	}
+	if opts.Generator == nil {
+		opts.Generator = utils.NewRandomGenerator(opts.CodeRules.Alphabet, opts.CodeRules.MaxLength)
+	}
+	if opts.Policy == nil {
+		opts.Policy = utils.NewCodePolicy(nil, nil)
 	}
+	if opts.Normalizer == nil {
+		opts.Normalizer = &utils.URLNormalizer{}
+	}
+	return &URLService{
+		urls:      urls,
+		cache:     urlCache,
+		filter:    filter,
+		urlFilter: urlFilter,
+		fallback:  utils.NewRandomGenerator(opts.CodeRules.Alphabet, opts.CodeRules.MaxLength),
+		opts:      opts,
+	}
+}
 
-	var shortCode string
-	if customAlias != "" {
-		// 检查自定义别名是否合法
-		if !utils.IsValidShortCode(customAlias) {
-			return "", errors.New("自定义别名不合法")
-		}
+// CreateOptions 创建短链接的选项
+type CreateOptions struct {
+	// OwnerID 创建者，只复用同一创建者的链接，为空时在匿名创建的链接之间复用
+	OwnerID string
+	// CustomAlias 自定义别名，为空时生成短码；指定别名时总是创建新链接
+	CustomAlias string
+	// Expiration 有效期，0 表示不过期
+	Expiration time.Duration
+	// ForceNew 总是创建新链接，不复用已有链接
+	ForceNew bool
+	// Title 标题，Tags 标签；指定任一项时总是创建新链接
+	Title string
+	Tags  []string
+}
+
+// CreateShortURL 创建短链接。未指定别名、标题和标签且未要求新建时，复用同一创建者对规范化后相同的URL、
+// 以相同有效期创建且剩余有效期覆盖本次请求的生成短码链接
+func (s *URLService) CreateShortURL(originalURL string, opts CreateOptions) (*models.URL, error) {
+	canonicalURL := s.opts.Normalizer.Normalize(originalURL)
+	expiresIn := int64(opts.Expiration / time.Second)
+	customAlias := s.canonical(opts.CustomAlias)
+	reuse := customAlias == "" && !opts.ForceNew && opts.Title == "" && len(opts.Tags) == 0
+
+	if utf8.RuneCountInString(opts.Title) > maxTitleLength {
+		return nil, ErrInvalidTitle
+	}
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
 
-		// 检查自定义别名是否已被使用
-		var existingAlias models.URL
-		result := database.DB.Where("short_code = ?", customAlias).First(&existingAlias)
-		if result.Error == nil {
-			return "", errors.New("自定义别名已被使用")
+	if reuse {
+		existingURL, err := s.urls.FindReusable(opts.OwnerID, canonicalURL, expiresIn, minReuseExpiresAt(time.Now(), opts.Expiration))
+		if err == nil {
+			return s.withTags(existingURL)
 		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
 
-		shortCode = customAlias
-	} else {
-		// 生成随机短码
-		for i := 0; i < 5; i++ { // 尝试5次
-			shortCode = utils.GenerateRandomShortCode()
-			var existingCode models.URL
-			result := database.DB.Where("short_code = ?", shortCode).First(&existingCode)
-			if result.Error == gorm.ErrRecordNotFound {
-				break
-			}
-			if i == 4 {
-				return "", errors.New("无法生成唯一短码")
-			}
+	if customAlias != "" {
+		if err := s.checkAlias(customAlias); err != nil {
+			return nil, err
 		}
 	}
 
 	// 设置过期时间
+	now := time.Now()
 	var expiresAt time.Time
-	if expiration > 0 {
-		expiresAt = time.Now().Add(expiration)
+	if opts.Expiration > 0 {
+		expiresAt = now.Add(opts.Expiration)
 	} else {
 		// 默认不过期，设置为100年后
-		expiresAt = time.Now().AddDate(100, 0, 0)
+		expiresAt = now.AddDate(100, 0, 0)
 	}
 
 	// 创建URL记录
 	url := models.URL{
-		OriginalURL: originalURL,
-		ShortCode:   shortCode,
-		CustomAlias: customAlias != "",
-		CreatedAt:   time.Now(),
-		ExpiresAt:   expiresAt,
+		OriginalURL:  originalURL,
+		CanonicalURL: canonicalURL,
+		OwnerID:      opts.OwnerID,
+		Title:        opts.Title,
+		Tags:         tags,
+		ShortCode:    customAlias,
+		CustomAlias:  customAlias != "",
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		ExpiresIn:    expiresIn,
 	}
 
-	if err := database.DB.Create(&url).Error; err != nil {
-		return "", err
+	if customAlias == "" {
+		var reused bool
+		if reused, err = s.createWithGeneratedCode(&url, reuse); err == nil && reused {
+			return &url, nil
+		}
+	} else {
+		err = s.urls.Create(&url)
+	}
+	if customAlias != "" && errors.Is(err, repository.ErrDuplicateKey) {
+		// 查询之后、写入之前别名被其他请求占用
+		return nil, ErrAliasTaken
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// 添加到缓存
-	cache.SetURL(shortCode, originalURL, expiration)
-	cache.SetURLLocal(shortCode, originalURL, expiration)
+	// 清除该短码在各实例上可能存在的负缓存，再写入缓存
+	s.invalidateURL(url.ShortCode)
+	s.cacheURL(url.ShortCode, originalURL, opts.Expiration)
 
 	// 添加到布隆过滤器
-	utils.ShortCodeFilter.Add(shortCode)
-	utils.OriginalURLFilter.Add(originalURL)
+	s.filter.Add(url.ShortCode)
+	s.urlFilter.Add(canonicalURL)
+
+	return &url, nil
+}
+
+// GetShortURL 查询短链接记录及其标签，包括已过期的
+func (s *URLService) GetShortURL(shortCode string) (*models.URL, error) {
+	url, err := s.urls.FindByShortCode(s.canonical(shortCode))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.withTags(url)
+}
+
+// GetOwnedShortURL 查询创建者 ownerID 的短链接记录，请求者为空或不是创建者时返回 ErrForbidden
+func (s *URLService) GetOwnedShortURL(shortCode, ownerID string) (*models.URL, error) {
+	url, err := s.GetShortURL(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(url, ownerID) {
+		return nil, ErrForbidden
+	}
+	return url, nil
+}
+
+// withTags 为记录填充标签
+func (s *URLService) withTags(url *models.URL) (*models.URL, error) {
+	tags, err := s.urls.TagsOf([]uint{url.ID})
+	if err != nil {
+		return nil, err
+	}
+	url.Tags = tags[url.ID]
+	return url, nil
+}
+
+// normalizeTags 标签去掉首尾空白并转为小写后去重排序，标签为空、过长或数量过多时返回 ErrInvalidTags
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > maxTags {
+		return nil, ErrInvalidTags
+	}
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTags
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// UpdateOptions 修改短链接的选项，为nil的字段保持不变
+type UpdateOptions struct {
+	// OriginalURL 新的目标URL
+	OriginalURL *string
+	// CustomAlias 新的短码，修改后链接视为自定义别名
+	CustomAlias *string
+	// Expiration 从现在起的新有效期，0 表示不过期
+	Expiration *time.Duration
+	// Title 新的标题，Tags 替换全部标签
+	Title *string
+	Tags  *[]string
+	// Version 期望的当前版本，为0时不检查
+	Version int64
+	// OwnerID 请求者，为空或与链接的创建者不一致时返回 ErrForbidden
+	OwnerID string
+}
+
+// UpdateShortURL 修改短链接的目标URL、过期时间、短码、标题或标签，并清除新旧短码的缓存、更新过滤器
+func (s *URLService) UpdateShortURL(shortCode string, opts UpdateOptions) (*models.URL, error) {
+	url, err := s.GetShortURL(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(url, opts.OwnerID) {
+		return nil, ErrForbidden
+	}
+	if url.Status == models.URLStatusDeleted {
+		return nil, ErrDeleted
+	}
+	if opts.Version != 0 && opts.Version != url.Version {
+		return nil, ErrVersionConflict
+	}
+	oldCode := url.ShortCode
+
+	if opts.OriginalURL != nil {
+		url.OriginalURL = *opts.OriginalURL
+		url.CanonicalURL = s.opts.Normalizer.Normalize(url.OriginalURL)
+	}
+	if opts.CustomAlias != nil {
+		alias := s.canonical(*opts.CustomAlias)
+		if alias != url.ShortCode {
+			if err := s.checkAlias(alias); err != nil {
+				return nil, err
+			}
+			url.ShortCode = alias
+			url.CustomAlias = true
+		}
+	}
+	if opts.Title != nil {
+		if utf8.RuneCountInString(*opts.Title) > maxTitleLength {
+			return nil, ErrInvalidTitle
+		}
+		url.Title = *opts.Title
+	}
+	var tags []string
+	if opts.Tags != nil {
+		if tags, err = normalizeTags(*opts.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Expiration != nil {
+		url.ExpiresIn = int64(*opts.Expiration / time.Second)
+		if *opts.Expiration > 0 {
+			url.ExpiresAt = time.Now().Add(*opts.Expiration)
+		} else {
+			url.ExpiresAt = time.Now().AddDate(100, 0, 0)
+		}
+	}
+
+	err = s.urls.Update(url)
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		return nil, ErrVersionConflict
+	case errors.Is(err, repository.ErrDuplicateKey):
+		// 查询之后、写入之前别名被其他请求占用
+		return nil, ErrAliasTaken
+	case err != nil:
+		return nil, err
+	}
+	if opts.Tags != nil {
+		if err := s.urls.SetTags(url.ID, tags); err != nil {
+			return nil, err
+		}
+		url.Tags = tags
+	}
+
+	// 各实例上的缓存都会失效，下次访问时回源读取修改后的记录
+	s.invalidateURL(oldCode)
+	if url.ShortCode != oldCode {
+		s.invalidateURL(url.ShortCode)
+		s.filter.Remove(oldCode)
+		s.filter.Add(url.ShortCode)
+	}
+	s.urlFilter.Add(url.CanonicalURL)
+
+	return url, nil
+}
+
+// DisableShortURL 停用创建者 ownerID 的链接，停用后访问返回停用提示，可重新启用
+func (s *URLService) DisableShortURL(shortCode, ownerID string) (*models.URL, error) {
+	return s.setStatus(shortCode, ownerID, models.URLStatusDisabled)
+}
+
+// EnableShortURL 重新启用创建者 ownerID 已停用的链接
+func (s *URLService) EnableShortURL(shortCode, ownerID string) (*models.URL, error) {
+	return s.setStatus(shortCode, ownerID, models.URLStatusActive)
+}
+
+// DeleteShortURL 软删除创建者 ownerID 的链接，短码保留在存储和过滤器中不再分配，之后访问返回已删除
+func (s *URLService) DeleteShortURL(shortCode, ownerID string) (*models.URL, error) {
+	return s.setStatus(shortCode, ownerID, models.URLStatusDeleted)
+}
+
+// setStatus 修改链接状态并清除各实例上的缓存。请求者 ownerID 为空或不是创建者时返回 ErrForbidden，已删除的链接不能再修改
+func (s *URLService) setStatus(shortCode, ownerID, status string) (*models.URL, error) {
+	url, err := s.GetShortURL(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if !ownedBy(url, ownerID) {
+		return nil, ErrForbidden
+	}
+	if url.Status == models.URLStatusDeleted {
+		return nil, ErrDeleted
+	}
+	if url.Status == status {
+		return url, nil
+	}
+
+	url.Status = status
+	if status == models.URLStatusDeleted {
+		now := time.Now()
+		url.DeletedAt = &now
+	}
+	if err := s.urls.Update(url); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return nil, ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	s.invalidateURL(url.ShortCode)
+	return url, nil
+}
+
+// checkAlias 检查自定义别名的长度和字符、保留词、屏蔽词以及是否已被使用
+func (s *URLService) checkAlias(alias string) error {
+	switch {
+	case !s.opts.AliasRules.Valid(alias):
+		return ErrInvalidAlias
+	case s.opts.Policy.Reserved(alias):
+		return ErrAliasReserved
+	case s.opts.Policy.Blocked(alias):
+		return ErrAliasBlocked
+	}
+
+	if _, err := s.urls.FindByShortCode(alias); err == nil {
+		return ErrAliasTaken
+	}
+	return nil
+}
+
+// createWithGeneratedCode 生成短码并写入记录。跳过保留词和包含屏蔽词的短码；直接写入而不预先查重，
+// 短码冲突由唯一约束检测，冲突时让生成器生成下一个短码重试。
+// 生成器以规范化后的URL为输入；允许复用时，若确定性生成器生成的短码已被可复用的记录（同一创建者、
+// 同一规范化URL、相同有效期且未过期的生成短码）占用，则直接复用，返回 reused=true。
+// 同一URL可能有多条不可复用的链接（不同创建者、有效期或强制新建），确定性序列的前几个短码都被占用时改用随机短码
+func (s *URLService) createWithGeneratedCode(url *models.URL, reuse bool) (reused bool, err error) {
+	deterministic := false
+	if g, ok := s.opts.Generator.(deterministicGenerator); ok {
+		deterministic = g.Deterministic()
+	}
+	attempts := maxGenerateAttempts
+	if deterministic {
+		attempts += maxGenerateAttempts
+	}
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		generator := s.opts.Generator
+		if attempt >= maxGenerateAttempts {
+			generator, deterministic = s.fallback, false
+		}
+		if url.ShortCode, err = generator.Generate(url.CanonicalURL, attempt); err != nil {
+			return false, err
+		}
+		if !s.opts.Policy.Allowed(url.ShortCode) {
+			continue
+		}
+
+		url.ID = 0
+		err = s.urls.Create(url)
+		if !errors.Is(err, repository.ErrDuplicateKey) {
+			return false, err
+		}
+
+		if deterministic && reuse {
+			existing, findErr := s.urls.FindByShortCode(url.ShortCode)
+			if findErr == nil && reusable(existing, url) {
+				*url = *existing
+				return true, nil
+			}
+		}
+	}
+	return false, errors.New("无法生成唯一短码")
+}
 
-	return shortCode, nil
+// reusable 已有记录 existing 能否作为待创建记录 url 返回
+func reusable(existing, url *models.URL) bool {
+	minExpiresAt := minReuseExpiresAt(time.Now(), time.Duration(url.ExpiresIn)*time.Second)
+	return !existing.CustomAlias && existing.OwnerID == url.OwnerID &&
+		existing.CanonicalURL == url.CanonicalURL && existing.ExpiresIn == url.ExpiresIn &&
+		existing.ExpiresAt.After(minExpiresAt)
+}
+
+// minReuseExpiresAt 可复用链接的过期时间下限：剩余有效期需覆盖请求的有效期 expiration，允许短 reuseTolerance；
+// 不过期的请求只要求链接尚未过期
+func minReuseExpiresAt(now time.Time, expiration time.Duration) time.Time {
+	if expiration > reuseTolerance {
+		return now.Add(expiration - reuseTolerance)
+	}
+	return now
+}
+
+// ownedBy 链接是否属于请求者 ownerID。匿名请求不拥有任何链接，匿名创建的链接因此不能查看详情或修改
+func ownedBy(url *models.URL, ownerID string) bool {
+	return ownerID != "" && url.OwnerID == ownerID
+}
+
+// canonical 返回短码的规范形式，大小写不敏感模式下为小写
+func (s *URLService) canonical(shortCode string) string {
+	if s.opts.CaseInsensitive {
+		return strings.ToLower(shortCode)
+	}
+	return shortCode
 }
 
 // GetOriginalURL 获取原始URL
-func GetOriginalURL(shortCode string) (string, error) {
+func (s *URLService) GetOriginalURL(shortCode string) (string, error) {
+	shortCode = s.canonical(shortCode)
+
 	// 检查短码是否合法
-	if !utils.IsValidShortCode(shortCode) {
-		return "", errors.New("短码不合法")
+	if !s.opts.CodeRules.Valid(shortCode) && !s.opts.AliasRules.Valid(shortCode) {
+		return "", ErrInvalidShortCode
 	}
 
 	// 检查布隆过滤器
-	if !utils.ShortCodeFilter.Contains(shortCode) {
-		return "", errors.New("短码不存在")
+	if !s.filter.MightContain(shortCode) {
+		return "", ErrNotFound
 	}
 
-	// 先查本地缓存
-	if originalURL, found := cache.GetURLLocal(shortCode); found {
-		go updateAccessStats(shortCode)
+	// 查缓存（本地缓存与Redis的分级由缓存层处理）
+	originalURL, err := cache.GetURL(s.cache, shortCode)
+	if err == nil {
+		// 命中负缓存，不再回源
+		switch originalURL {
+		case cache.NotFoundMarker:
+			return "", ErrNotFound
+		case cache.ExpiredMarker:
+			return "", ErrExpired
+		case cache.DisabledMarker:
+			return "", ErrDisabled
+		case cache.DeletedMarker:
+			return "", ErrDeleted
+		}
+		go s.updateAccessStats(shortCode)
 		return originalURL, nil
 	}
+	if !errors.Is(err, cache.ErrCacheMiss) && !errors.Is(err, cache.ErrUnavailable) {
+		log.Printf("读取缓存失败: %v", err)
+	}
 
-	// 查Redis缓存
-	originalURL, err := cache.GetURL(shortCode)
-	if err == nil {
-		// 更新本地缓存
-		cache.SetURLLocal(shortCode, originalURL, 30*time.Minute)
-		go updateAccessStats(shortCode)
-		return originalURL, nil
+	// 查数据库，同一短码的并发查询只回源一次并共享结果
+	result, err, _ := s.loads.Do(shortCode, func() (interface{}, error) {
+		return s.loadURL(shortCode)
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// 查数据库
-	var url models.URL
-	result := database.DB.Where("short_code = ?", shortCode).First(&url)
-	if result.Error != nil {
-		return "", result.Error
+	// 异步更新访问统计
+	go s.updateAccessStats(shortCode)
+
+	return result.(string), nil
+}
+
+// loadURL 从数据库加载原始URL并写入缓存，不存在或已过期时写入负缓存
+func (s *URLService) loadURL(shortCode string) (string, error) {
+	url, err := s.urls.FindByShortCode(shortCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.cacheURL(shortCode, cache.NotFoundMarker, s.opts.NegativeTTL)
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 检查状态，已删除的短码保留在过滤器和存储中，以区别于不存在的短码
+	switch url.Status {
+	case models.URLStatusDeleted:
+		s.cacheURL(shortCode, cache.DeletedMarker, s.opts.NegativeTTL)
+		return "", ErrDeleted
+	case models.URLStatusDisabled:
+		s.cacheURL(shortCode, cache.DisabledMarker, s.opts.NegativeTTL)
+		return "", ErrDisabled
 	}
 
 	// 检查是否过期
 	if url.ExpiresAt.Before(time.Now()) {
-		return "", errors.New("链接已过期")
+		s.cacheURL(shortCode, cache.ExpiredMarker, s.opts.NegativeTTL)
+		return "", ErrExpired
 	}
 
 	// 更新缓存
-	cache.SetURL(shortCode, url.OriginalURL, time.Until(url.ExpiresAt))
-	cache.SetURLLocal(shortCode, url.OriginalURL, 30*time.Minute)
-
-	// 异步更新访问统计
-	go updateAccessStats(shortCode)
+	s.cacheURL(shortCode, url.OriginalURL, time.Until(url.ExpiresAt))
 
 	return url.OriginalURL, nil
 }
 
+// cacheURL 写入缓存，缓存故障不影响主流程。缓存键使用短码的规范形式
+func (s *URLService) cacheURL(shortCode, originalURL string, expiration time.Duration) {
+	if err := cache.SetURL(s.cache, s.canonical(shortCode), originalURL, expiration); err != nil &&
+		!errors.Is(err, cache.ErrUnavailable) {
+		log.Printf("写入缓存失败: %v", err)
+	}
+}
+
+// invalidateURL 删除短码在所有实例上的缓存。存储中开启大小写不敏感模式之前的短码保留原有大小写，
+// 而缓存键总是规范形式，因此先转换
+func (s *URLService) invalidateURL(shortCode string) {
+	if err := cache.DeleteURL(s.cache, s.canonical(shortCode)); err != nil &&
+		!errors.Is(err, cache.ErrUnavailable) {
+		log.Printf("删除缓存失败: %v", err)
+	}
+}
+
 // 更新访问统计
-func updateAccessStats(shortCode string) {
+func (s *URLService) updateAccessStats(shortCode string) {
 	// 增加计数器
-	cache.IncrementCounter(shortCode)
+	cache.IncrementCounter(s.cache, shortCode)
 
 	// 更新数据库访问计数和最后访问时间
-	database.DB.Model(&models.URL{}).
-		Where("short_code = ?", shortCode).
-		Updates(map[string]interface{}{
-			"access_count":   gorm.Expr("access_count + 1"),
-			"last_access_at": time.Now(),
-		})
+	s.urls.IncrementAccess(shortCode, time.Now())
+}
+
+// DeleteExpiredURLs 删除过期URL，并从过滤器中移除对应短码，返回删除数量
+func (s *URLService) DeleteExpiredURLs() (int, error) {
+	shortCodes, err := s.urls.DeleteExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, shortCode := range shortCodes {
+		s.filter.Remove(shortCode)
+		s.invalidateURL(shortCode)
+	}
+	return len(shortCodes), nil
 }
 
-// DeleteExpiredURLs 删除过期URL
-func DeleteExpiredURLs() error {
-	return database.DB.Where("expires_at < ?", time.Now()).Delete(&models.URL{}).Error
+// RunCleanupLoop 定期删除过期URL，直到 stop 关闭
+func (s *URLService) RunCleanupLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := s.DeleteExpiredURLs()
+			if err != nil {
+				log.Printf("删除过期URL失败: %v", err)
+			} else if deleted > 0 {
+				log.Printf("已删除 %d 个过期URL", deleted)
+			}
+		case <-stop:
+			return
+		}
+	}
 }
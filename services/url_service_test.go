@@ -0,0 +1,525 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keenJoe/go-url-shortener/cache"
+	"github.com/keenJoe/go-url-shortener/models"
+	"github.com/keenJoe/go-url-shortener/repository"
+	"github.com/keenJoe/go-url-shortener/utils"
+)
+
+// newTestService 基于内存存储和仅本地缓存创建短链接服务，过滤器已预热
+func newTestService(t *testing.T, opts URLServiceOptions) (*URLService, *repository.MemoryURLRepository) {
+	t.Helper()
+	urls := repository.NewMemoryURLRepository()
+	return newTestServiceWithRepo(t, urls, opts), urls
+}
+
+// newTestServiceWithRepo 基于已有的内存存储创建短链接服务
+func newTestServiceWithRepo(t *testing.T, urls *repository.MemoryURLRepository, opts URLServiceOptions) *URLService {
+	t.Helper()
+	filter := NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), nil, 0, opts.CaseInsensitive)
+	if err := filter.WarmUp(); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	urlCache := cache.NewLocalOnlyCache(cache.NewLocalCache(0, 0))
+	return NewURLService(urls, urlCache, filter, utils.NewBloomFilter(1000, 0.01), opts)
+}
+
+func TestCreateShortURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   string
+		wantErr error
+	}{
+		{name: "generated"},
+		{name: "custom alias", alias: "my-link"},
+		{name: "invalid alias", alias: "a b", wantErr: ErrInvalidAlias},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, URLServiceOptions{})
+
+			url, err := s.CreateShortURL("https://example.com/page", CreateOptions{CustomAlias: tt.alias})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateShortURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if tt.alias != "" && url.ShortCode != tt.alias {
+				t.Errorf("ShortCode = %q, want %q", url.ShortCode, tt.alias)
+			}
+			if !s.filter.MightContain(url.ShortCode) || !s.urlFilter.Contains(url.CanonicalURL) {
+				t.Errorf("short code or canonical URL not added to filters")
+			}
+
+			got, err := s.GetOriginalURL(url.ShortCode)
+			if err != nil || got != "https://example.com/page" {
+				t.Errorf("GetOriginalURL() = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestCreateShortURLAliasTaken(t *testing.T) {
+	s, _ := newTestService(t, URLServiceOptions{})
+
+	if _, err := s.CreateShortURL("https://example.com/a", CreateOptions{CustomAlias: "taken"}); err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if _, err := s.CreateShortURL("https://example.com/b", CreateOptions{CustomAlias: "taken"}); !errors.Is(err, ErrAliasTaken) {
+		t.Errorf("CreateShortURL() error = %v, want %v", err, ErrAliasTaken)
+	}
+}
+
+func TestGetOriginalURL(t *testing.T) {
+	s, urls := newTestService(t, URLServiceOptions{})
+	expiring, err := s.CreateShortURL("https://example.com/soon", CreateOptions{Expiration: time.Hour})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	// 直接修改存储中的过期时间并清除缓存，模拟链接已过期
+	stored, _ := urls.FindByShortCode(expiring.ShortCode)
+	stored.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := urls.Update(stored); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	s.invalidateURL(expiring.ShortCode)
+
+	tests := []struct {
+		name      string
+		shortCode string
+		wantErr   error
+	}{
+		{name: "invalid", shortCode: "???", wantErr: ErrInvalidShortCode},
+		{name: "unknown", shortCode: "zzzzzzz", wantErr: ErrNotFound},
+		{name: "expired", shortCode: expiring.ShortCode, wantErr: ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.GetOriginalURL(tt.shortCode); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetOriginalURL(%q) error = %v, want %v", tt.shortCode, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateShortURLHashFallback(t *testing.T) {
+	s, _ := newTestService(t, URLServiceOptions{
+		Generator: utils.NewHashGenerator(utils.MustAlphabet(utils.Base62Chars), 7, "secret", ""),
+	})
+
+	// 不同创建者的链接不能复用，确定性序列用完后改用随机短码
+	codes := make(map[string]bool)
+	for i := 0; i < 3*maxGenerateAttempts; i++ {
+		url, err := s.CreateShortURL("https://google.com/", CreateOptions{OwnerID: "owner-" + strconv.Itoa(i)})
+		if err != nil {
+			t.Fatalf("CreateShortURL() #%d error = %v", i, err)
+		}
+		if codes[url.ShortCode] {
+			t.Fatalf("CreateShortURL() #%d reused %s from another owner", i, url.ShortCode)
+		}
+		codes[url.ShortCode] = true
+	}
+
+	// 同一创建者仍然复用
+	first, err := s.CreateShortURL("https://google.com/", CreateOptions{OwnerID: "owner-0"})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	again, err := s.CreateShortURL("https://google.com/", CreateOptions{OwnerID: "owner-0"})
+	if err != nil || again.ShortCode != first.ShortCode {
+		t.Errorf("CreateShortURL() = %v, %v, want reuse of %s", again, err, first.ShortCode)
+	}
+}
+
+func TestCaseInsensitiveInvalidation(t *testing.T) {
+	// 开启大小写不敏感模式之前创建的短码在存储中保留原有大小写
+	urls := repository.NewMemoryURLRepository()
+	legacy := &models.URL{OriginalURL: "https://example.com/old", ShortCode: "AbCdEfG", OwnerID: "alice", CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour)}
+	if err := urls.Create(legacy); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := urls.EnableCaseInsensitive(); err != nil {
+		t.Fatalf("EnableCaseInsensitive() error = %v", err)
+	}
+	s := newTestServiceWithRepo(t, urls, URLServiceOptions{CaseInsensitive: true})
+
+	tests := []struct {
+		name    string
+		change  func() error
+		lookup  string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "update",
+			change: func() error {
+				target := "https://example.com/new"
+				_, err := s.UpdateShortURL("ABCDEFG", UpdateOptions{OriginalURL: &target, OwnerID: "alice"})
+				return err
+			},
+			lookup: "abcdefg",
+			want:   "https://example.com/new",
+		},
+		{
+			name: "disable",
+			change: func() error {
+				_, err := s.DisableShortURL("AbCdEfG", "alice")
+				return err
+			},
+			lookup:  "ABCDEFG",
+			wantErr: ErrDisabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 先访问一次写入缓存
+			if _, err := s.GetOriginalURL(tt.lookup); err != nil {
+				t.Fatalf("GetOriginalURL() error = %v", err)
+			}
+			if err := tt.change(); err != nil {
+				t.Fatalf("change error = %v", err)
+			}
+			got, err := s.GetOriginalURL(tt.lookup)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetOriginalURL() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateShortURLDedup(t *testing.T) {
+	tests := []struct {
+		name      string
+		second    string
+		opts      CreateOptions
+		wantReuse bool
+	}{
+		{name: "same canonical URL", second: "HTTPS://Example.com:443/spa/#/home", wantReuse: true},
+		{name: "different fragment", second: "https://example.com/spa#/settings"},
+		{name: "different owner", second: "https://example.com/spa#/home", opts: CreateOptions{OwnerID: "other"}},
+		{name: "force new", second: "https://example.com/spa#/home", opts: CreateOptions{ForceNew: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, URLServiceOptions{})
+			first, err := s.CreateShortURL("https://example.com/spa#/home", CreateOptions{})
+			if err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+			second, err := s.CreateShortURL(tt.second, tt.opts)
+			if err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+			if reused := second.ShortCode == first.ShortCode; reused != tt.wantReuse {
+				t.Errorf("reused = %v, want %v", reused, tt.wantReuse)
+			}
+		})
+	}
+}
+
+func TestCreateShortURLReuseRemainingLifetime(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiration time.Duration
+		// age 已有链接已经存在的时长
+		age       time.Duration
+		wantReuse bool
+	}{
+		{name: "fresh", expiration: 24 * time.Hour, age: 0, wantReuse: true},
+		{name: "within tolerance", expiration: 24 * time.Hour, age: 30 * time.Second, wantReuse: true},
+		{name: "lifetime partly used", expiration: 24 * time.Hour, age: time.Hour},
+		{name: "shorter than tolerance", expiration: 30 * time.Second, age: 10 * time.Second, wantReuse: true},
+		{name: "never expires", expiration: 0, age: 365 * 24 * time.Hour, wantReuse: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, generator := range []utils.ShortCodeGenerator{
+				nil,
+				utils.NewHashGenerator(utils.MustAlphabet(utils.Base62Chars), 7, "secret", ""),
+			} {
+				s, urls := newTestService(t, URLServiceOptions{Generator: generator})
+				first, err := s.CreateShortURL("https://example.com/", CreateOptions{Expiration: tt.expiration})
+				if err != nil {
+					t.Fatalf("CreateShortURL() error = %v", err)
+				}
+				// 把已有链接的创建和过期时间前移，模拟已经存在了 age
+				stored, _ := urls.FindByShortCode(first.ShortCode)
+				stored.CreatedAt = stored.CreatedAt.Add(-tt.age)
+				stored.ExpiresAt = stored.ExpiresAt.Add(-tt.age)
+				if err := urls.Update(stored); err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+
+				second, err := s.CreateShortURL("https://example.com/", CreateOptions{Expiration: tt.expiration})
+				if err != nil {
+					t.Fatalf("CreateShortURL() error = %v", err)
+				}
+				if reused := second.ShortCode == first.ShortCode; reused != tt.wantReuse {
+					t.Errorf("generator %T: reused = %v, want %v", generator, reused, tt.wantReuse)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateShortURL(t *testing.T) {
+	target := "https://example.com/new"
+	alias := "renamed"
+	taken := "taken"
+	tests := []struct {
+		name     string
+		opts     UpdateOptions
+		wantErr  error
+		wantCode string
+		wantURL  string
+	}{
+		{name: "retarget", opts: UpdateOptions{OriginalURL: &target, OwnerID: "alice"}, wantURL: target},
+		{name: "matching version", opts: UpdateOptions{OriginalURL: &target, Version: 1, OwnerID: "alice"}, wantURL: target},
+		{name: "version conflict", opts: UpdateOptions{OriginalURL: &target, Version: 2, OwnerID: "alice"}, wantErr: ErrVersionConflict},
+		{name: "rename", opts: UpdateOptions{CustomAlias: &alias, OwnerID: "alice"}, wantCode: alias},
+		{name: "rename to taken alias", opts: UpdateOptions{CustomAlias: &taken, OwnerID: "alice"}, wantErr: ErrAliasTaken},
+		{name: "other owner", opts: UpdateOptions{OriginalURL: &target, OwnerID: "bob"}, wantErr: ErrForbidden},
+		{name: "anonymous", opts: UpdateOptions{OriginalURL: &target}, wantErr: ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, URLServiceOptions{})
+			if _, err := s.CreateShortURL("https://example.com/other", CreateOptions{CustomAlias: taken}); err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+			url, err := s.CreateShortURL("https://example.com/old", CreateOptions{OwnerID: "alice"})
+			if err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+			oldCode := url.ShortCode
+			// 先访问一次写入缓存
+			if _, err := s.GetOriginalURL(oldCode); err != nil {
+				t.Fatalf("GetOriginalURL() error = %v", err)
+			}
+
+			updated, err := s.UpdateShortURL(oldCode, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateShortURL() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if got, err := s.GetOriginalURL(oldCode); err != nil || got != "https://example.com/old" {
+					t.Errorf("GetOriginalURL() after failed update = %q, %v", got, err)
+				}
+				return
+			}
+			if updated.Version != 2 {
+				t.Errorf("Version = %d, want 2", updated.Version)
+			}
+
+			code, want := oldCode, "https://example.com/old"
+			if tt.wantCode != "" {
+				code = tt.wantCode
+				if _, err := s.GetOriginalURL(oldCode); !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetOriginalURL(old code) error = %v, want %v", err, ErrNotFound)
+				}
+			}
+			if tt.wantURL != "" {
+				want = tt.wantURL
+			}
+			if got, err := s.GetOriginalURL(code); err != nil || got != want {
+				t.Errorf("GetOriginalURL(%q) = %q, %v, want %q", code, got, err, want)
+			}
+		})
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps 依次执行的状态操作：disable、enable、delete
+		steps        []string
+		wantErr      error
+		wantStatus   string
+		wantRedirect error
+	}{
+		{name: "disable", steps: []string{"disable"}, wantStatus: models.URLStatusDisabled, wantRedirect: ErrDisabled},
+		{name: "disable twice", steps: []string{"disable", "disable"}, wantStatus: models.URLStatusDisabled, wantRedirect: ErrDisabled},
+		{name: "enable", steps: []string{"disable", "enable"}, wantStatus: models.URLStatusActive},
+		{name: "delete", steps: []string{"delete"}, wantStatus: models.URLStatusDeleted, wantRedirect: ErrDeleted},
+		{name: "delete disabled", steps: []string{"disable", "delete"}, wantStatus: models.URLStatusDeleted, wantRedirect: ErrDeleted},
+		{name: "enable deleted", steps: []string{"delete", "enable"}, wantErr: ErrDeleted, wantStatus: models.URLStatusDeleted, wantRedirect: ErrDeleted},
+		{name: "delete deleted", steps: []string{"delete", "delete"}, wantErr: ErrDeleted, wantStatus: models.URLStatusDeleted, wantRedirect: ErrDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, URLServiceOptions{})
+			url, err := s.CreateShortURL("https://example.com/", CreateOptions{OwnerID: "alice"})
+			if err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+			// 先访问一次写入缓存
+			if _, err := s.GetOriginalURL(url.ShortCode); err != nil {
+				t.Fatalf("GetOriginalURL() error = %v", err)
+			}
+
+			actions := map[string]func(shortCode, ownerID string) (*models.URL, error){
+				"disable": s.DisableShortURL,
+				"enable":  s.EnableShortURL,
+				"delete":  s.DeleteShortURL,
+			}
+			for i, step := range tt.steps {
+				_, err = actions[step](url.ShortCode, "alice")
+				if i < len(tt.steps)-1 && err != nil {
+					t.Fatalf("%s error = %v", step, err)
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("last step error = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := s.GetShortURL(url.ShortCode)
+			if err != nil {
+				t.Fatalf("GetShortURL() error = %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", stored.Status, tt.wantStatus)
+			}
+			if deleted := stored.DeletedAt != nil; deleted != (tt.wantStatus == models.URLStatusDeleted) {
+				t.Errorf("DeletedAt = %v", stored.DeletedAt)
+			}
+			if _, err := s.GetOriginalURL(url.ShortCode); !errors.Is(err, tt.wantRedirect) {
+				t.Errorf("GetOriginalURL() error = %v, want %v", err, tt.wantRedirect)
+			}
+		})
+	}
+}
+
+func TestUpdateDeletedShortURL(t *testing.T) {
+	s, _ := newTestService(t, URLServiceOptions{})
+	url, err := s.CreateShortURL("https://example.com/", CreateOptions{OwnerID: "alice"})
+	if err != nil {
+		t.Fatalf("CreateShortURL() error = %v", err)
+	}
+	if _, err := s.DeleteShortURL(url.ShortCode, "alice"); err != nil {
+		t.Fatalf("DeleteShortURL() error = %v", err)
+	}
+
+	target := "https://example.com/new"
+	if _, err := s.UpdateShortURL(url.ShortCode, UpdateOptions{OriginalURL: &target, OwnerID: "alice"}); !errors.Is(err, ErrDeleted) {
+		t.Errorf("UpdateShortURL() error = %v, want %v", err, ErrDeleted)
+	}
+	// 已删除的短码不会再分配
+	if _, err := s.CreateShortURL("https://example.com/other", CreateOptions{CustomAlias: url.ShortCode}); !errors.Is(err, ErrAliasTaken) {
+		t.Errorf("CreateShortURL() with deleted code error = %v, want %v", err, ErrAliasTaken)
+	}
+}
+
+func TestModifyRequiresOwner(t *testing.T) {
+	target := "https://example.com/new"
+	tests := []struct {
+		name      string
+		linkOwner string
+		ownerID   string
+	}{
+		{name: "empty owner on anonymous link", linkOwner: "", ownerID: ""},
+		{name: "empty owner on owned link", linkOwner: "alice", ownerID: ""},
+		{name: "owner on anonymous link", linkOwner: "", ownerID: "alice"},
+		{name: "other owner", linkOwner: "alice", ownerID: "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, URLServiceOptions{})
+			url, err := s.CreateShortURL("https://example.com/", CreateOptions{OwnerID: tt.linkOwner})
+			if err != nil {
+				t.Fatalf("CreateShortURL() error = %v", err)
+			}
+
+			actions := map[string]func(shortCode, ownerID string) (*models.URL, error){
+				"update": func(shortCode, ownerID string) (*models.URL, error) {
+					return s.UpdateShortURL(shortCode, UpdateOptions{OriginalURL: &target, OwnerID: ownerID})
+				},
+				"disable": s.DisableShortURL,
+				"enable":  s.EnableShortURL,
+				"delete":  s.DeleteShortURL,
+			}
+			for name, action := range actions {
+				if _, err := action(url.ShortCode, tt.ownerID); !errors.Is(err, ErrForbidden) {
+					t.Errorf("%s error = %v, want %v", name, err, ErrForbidden)
+				}
+			}
+
+			stored, err := s.GetShortURL(url.ShortCode)
+			if err != nil {
+				t.Fatalf("GetShortURL() error = %v", err)
+			}
+			if stored.Status != models.URLStatusActive || stored.OriginalURL != "https://example.com/" {
+				t.Errorf("link modified: status %q, url %q", stored.Status, stored.OriginalURL)
+			}
+		})
+	}
+}
+
+// countingRepository 统计 FindByShortCode 的调用次数，首次调用阻塞到 release 关闭
+type countingRepository struct {
+	*repository.MemoryURLRepository
+	finds   atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r *countingRepository) FindByShortCode(shortCode string) (*models.URL, error) {
+	if r.finds.Add(1) == 1 {
+		close(r.entered)
+	}
+	<-r.release
+	return r.MemoryURLRepository.FindByShortCode(shortCode)
+}
+
+func TestGetOriginalURLSingleflight(t *testing.T) {
+	memory := repository.NewMemoryURLRepository()
+	url := &models.URL{OriginalURL: "https://example.com/", ShortCode: "abcdefg", CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour)}
+	if err := memory.Create(url); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	urls := &countingRepository{MemoryURLRepository: memory, entered: make(chan struct{}), release: make(chan struct{})}
+	filter := NewFilterService(urls, utils.NewBloomFilter(1000, 0.01), nil, 0, false)
+	filter.Add(url.ShortCode)
+	s := NewURLService(urls, cache.NewLocalOnlyCache(cache.NewLocalCache(0, 0)), filter, utils.NewBloomFilter(1000, 0.01),
+		URLServiceOptions{})
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := s.GetOriginalURL(url.ShortCode)
+			if err == nil && got != url.OriginalURL {
+				err = errors.New("unexpected URL " + got)
+			}
+			errs <- err
+		}()
+	}
+	// 第一次回源阻塞期间其余请求都在等待同一次查询
+	<-urls.entered
+	time.Sleep(50 * time.Millisecond)
+	close(urls.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetOriginalURL() error = %v", err)
+		}
+	}
+	if finds := urls.finds.Load(); finds != 1 {
+		t.Errorf("FindByShortCode called %d times, want 1", finds)
+	}
+}
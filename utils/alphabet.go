@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+const (
+	// Base62Chars 默认短码字符集
+	Base62Chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// maxCodeSpace 编码空间上限，保证置换使用的Feistel网络不超过64位
+	maxCodeSpace uint64 = 1 << 62
+)
+
+// ErrIDOutOfRange ID超出固定长度短码可表示的范围
+var ErrIDOutOfRange = errors.New("ID超出短码可表示的范围")
+
+// Alphabet 短码字符集，支持按固定长度进行进制编码
+type Alphabet struct {
+	chars string
+	index [256]int16 // 字符在字符集中的位置，-1 表示不在字符集中
+}
+
+// NewAlphabet 创建字符集，字符必须为不重复的ASCII可打印字符，且至少2个
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 2 {
+		return nil, errors.New("字符集至少需要2个字符")
+	}
+
+	a := &Alphabet{chars: chars}
+	for i := range a.index {
+		a.index[i] = -1
+	}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c <= ' ' || c >= 0x7f {
+			return nil, fmt.Errorf("字符集包含不支持的字符: %q", c)
+		}
+		if a.index[c] >= 0 {
+			return nil, fmt.Errorf("字符集包含重复字符: %q", c)
+		}
+		a.index[c] = int16(i)
+	}
+	return a, nil
+}
+
+// MustAlphabet 创建字符集，字符集不合法时panic，用于内置字符集
+func MustAlphabet(chars string) *Alphabet {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Chars 返回字符集中的全部字符
+func (a *Alphabet) Chars() string {
+	return a.chars
+}
+
+// Lower 返回转为小写并去重后的字符集，用于大小写不敏感模式
+func (a *Alphabet) Lower() (*Alphabet, error) {
+	seen := make(map[byte]bool, len(a.chars))
+	lower := make([]byte, 0, len(a.chars))
+	for _, c := range []byte(strings.ToLower(a.chars)) {
+		if !seen[c] {
+			seen[c] = true
+			lower = append(lower, c)
+		}
+	}
+	return NewAlphabet(string(lower))
+}
+
+// Contains 短码是否只包含字符集中的字符
+func (a *Alphabet) Contains(code string) bool {
+	for i := 0; i < len(code); i++ {
+		if a.index[code[i]] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Space 指定长度的短码可表示的ID个数，超过上限时返回上限
+func (a *Alphabet) Space(length int) uint64 {
+	base := uint64(len(a.chars))
+	space := uint64(1)
+	for i := 0; i < length; i++ {
+		hi, lo := bits.Mul64(space, base)
+		if hi != 0 || lo > maxCodeSpace {
+			return maxCodeSpace
+		}
+		space = lo
+	}
+	return space
+}
+
+// Encode 将ID编码为固定长度的短码，不足长度时左侧补字符集首字符
+func (a *Alphabet) Encode(id uint64, length int) (string, error) {
+	if id >= a.Space(length) {
+		return "", ErrIDOutOfRange
+	}
+
+	base := uint64(len(a.chars))
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		b[i] = a.chars[id%base]
+		id /= base
+	}
+	return string(b), nil
+}
+
+// Decode 将短码解码为ID
+func (a *Alphabet) Decode(code string) (uint64, error) {
+	if !a.Contains(code) {
+		return 0, errors.New("短码包含字符集之外的字符")
+	}
+
+	base := uint64(len(a.chars))
+	var id uint64
+	for i := 0; i < len(code); i++ {
+		hi, lo := bits.Mul64(id, base)
+		if hi != 0 {
+			return 0, ErrIDOutOfRange
+		}
+		id = lo + uint64(a.index[code[i]])
+	}
+	return id, nil
+}
+
+// PermuteID 在 [0, space) 上对ID做由 key 决定的可逆置换，使连续ID生成的短码不可预测。
+// 使用覆盖 space 的偶数位Feistel网络，结果超出范围时继续置换（cycle walking）直到落入范围内
+func PermuteID(id, key, space uint64) uint64 {
+	half := feistelHalfBits(space)
+	for {
+		id = feistel(id, key, half)
+		if id < space {
+			return id
+		}
+	}
+}
+
+// UnpermuteID PermuteID 的逆运算
+func UnpermuteID(permuted, key, space uint64) uint64 {
+	half := feistelHalfBits(space)
+	for {
+		permuted = unfeistel(permuted, key, half)
+		if permuted < space {
+			return permuted
+		}
+	}
+}
+
+// feistelRounds Feistel网络轮数
+const feistelRounds = 4
+
+// feistelHalfBits 覆盖 [0, space) 所需的Feistel网络半宽位数
+func feistelHalfBits(space uint64) uint {
+	return uint(bits.Len64(space-1)+1) / 2
+}
+
+// feistel Feistel网络加密
+func feistel(x, key uint64, half uint) uint64 {
+	mask := uint64(1)<<half - 1
+	l, r := x>>half, x&mask
+	for round := uint64(0); round < feistelRounds; round++ {
+		l, r = r, l^feistelRound(r, key, round)&mask
+	}
+	return l<<half | r
+}
+
+// unfeistel Feistel网络解密
+func unfeistel(x, key uint64, half uint) uint64 {
+	mask := uint64(1)<<half - 1
+	l, r := x>>half, x&mask
+	for round := uint64(feistelRounds); round > 0; round-- {
+		l, r = r^feistelRound(l, key, round-1)&mask, l
+	}
+	return l<<half | r
+}
+
+// feistelRound 轮函数
+func feistelRound(half, key, round uint64) uint64 {
+	return fmix64(half ^ key ^ (round+1)*0x9e3779b97f4a7c15)
+}
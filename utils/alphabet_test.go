@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPermuteID(t *testing.T) {
+	tests := []struct {
+		name  string
+		space uint64
+		key   uint64
+	}{
+		{name: "small odd space", space: 1000, key: 1},
+		{name: "power of two", space: 1024, key: 42},
+		{name: "not a power of four", space: 62 * 62 * 62, key: 0xdeadbeef},
+		{name: "two", space: 2, key: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 在整个空间上是双射，且逆运算还原原值
+			seen := make([]bool, tt.space)
+			for id := uint64(0); id < tt.space; id++ {
+				permuted := PermuteID(id, tt.key, tt.space)
+				if permuted >= tt.space {
+					t.Fatalf("PermuteID(%d) = %d out of space %d", id, permuted, tt.space)
+				}
+				if seen[permuted] {
+					t.Fatalf("PermuteID(%d) = %d collides", id, permuted)
+				}
+				seen[permuted] = true
+				if got := UnpermuteID(permuted, tt.key, tt.space); got != id {
+					t.Fatalf("UnpermuteID(PermuteID(%d)) = %d", id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPermuteIDLargeSpace(t *testing.T) {
+	alphabet := MustAlphabet(Base62Chars)
+	for _, length := range []int{7, 10, 11} {
+		space := alphabet.Space(length)
+		for _, id := range []uint64{0, 1, 2, 12345, space / 2, space - 1} {
+			permuted := PermuteID(id, 99, space)
+			if permuted >= space || UnpermuteID(permuted, 99, space) != id {
+				t.Errorf("length %d: PermuteID(%d) = %d does not round-trip", length, id, permuted)
+			}
+		}
+		if PermuteID(1, 99, space) == PermuteID(1, 100, space) {
+			t.Errorf("length %d: different keys give the same permutation", length)
+		}
+	}
+}
+
+func TestAlphabetEncode(t *testing.T) {
+	base62 := MustAlphabet(Base62Chars)
+	tests := []struct {
+		name    string
+		id      uint64
+		length  int
+		want    string
+		wantErr error
+	}{
+		{name: "zero pads", id: 0, length: 4, want: "aaaa"},
+		{name: "one", id: 1, length: 4, want: "aaab"},
+		{name: "base", id: 62, length: 3, want: "aba"},
+		{name: "max", id: 62*62 - 1, length: 2, want: "99"},
+		{name: "out of range", id: 62 * 62, length: 2, wantErr: ErrIDOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := base62.Encode(tt.id, tt.length)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("Encode(%d, %d) = %q, %v, want %q, %v", tt.id, tt.length, got, err, tt.want, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if id, err := base62.Decode(got); err != nil || id != tt.id {
+				t.Errorf("Decode(%q) = %d, %v, want %d", got, id, err, tt.id)
+			}
+		})
+	}
+}
+
+func TestNewAlphabet(t *testing.T) {
+	tests := []struct {
+		chars   string
+		wantErr bool
+	}{
+		{chars: "ab"},
+		{chars: Base62Chars},
+		{chars: "a", wantErr: true},
+		{chars: "aba", wantErr: true},
+		{chars: "a b", wantErr: true},
+	}
+	for _, tt := range tests {
+		if _, err := NewAlphabet(tt.chars); (err != nil) != tt.wantErr {
+			t.Errorf("NewAlphabet(%q) error = %v, wantErr %v", tt.chars, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	alphabet := MustAlphabet("0123456789")
+	tests := []struct {
+		name      string
+		obfuscate bool
+	}{
+		{name: "plain"},
+		{name: "obfuscated", obfuscate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next uint64 = 1
+			source := func(size uint64) (uint64, error) {
+				start := next
+				next += size
+				return start, nil
+			}
+			g := NewSequenceGenerator(NewBlockAllocator(source, 10), alphabet, 3, tt.obfuscate, 7)
+			seen := make(map[string]bool)
+			for i := 0; i < 500; i++ {
+				code, err := g.Generate("", 0)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+				if len(code) != 3 || seen[code] {
+					t.Fatalf("Generate() = %q, duplicate or wrong length", code)
+				}
+				seen[code] = true
+			}
+		})
+	}
+
+	// 超出编码空间后返回错误
+	g := NewSequenceGenerator(NewBlockAllocator(func(uint64) (uint64, error) { return 1000, nil }, 1), alphabet, 3, false, 0)
+	if _, err := g.Generate("", 0); !errors.Is(err, ErrIDOutOfRange) {
+		t.Errorf("Generate() beyond space error = %v, want %v", err, ErrIDOutOfRange)
+	}
+}
@@ -1,68 +1,156 @@
 package utils
 
 import (
+	"encoding/binary"
 	"hash/fnv"
+	"math"
 	"sync"
 )
 
-// 简单的布隆过滤器实现
+// 默认容量与假阳性率
+const (
+	defaultExpectedItems     = 10000000
+	defaultFalsePositiveRate = 0.01
+)
+
+// Filter 短码成员过滤器，Contains 返回false时元素一定不存在
+type Filter interface {
+	// Add 添加元素
+	Add(item string)
+	// Contains 检查元素是否可能存在
+	Contains(item string) bool
+	// Stats 返回统计信息
+	Stats() BloomFilterStats
+}
+
+// BloomFilter 位压缩的布隆过滤器，使用双重哈希生成k个位置
 type BloomFilter struct {
-	bitset []bool
-	size   uint
-	mutex  sync.RWMutex
+	mutex   sync.RWMutex
+	bitset  []uint64
+	m       uint64 // 位数
+	k       uint64 // 哈希函数个数
+	setBits uint64 // 已置位的位数
+	items   uint64 // 已添加的元素数（含重复添加）
 }
 
-// NewBloomFilter 创建新的布隆过滤器
-func NewBloomFilter(size uint) *BloomFilter {
+// BloomFilterStats 布隆过滤器统计
+type BloomFilterStats struct {
+	Bits          uint64  `json:"bits"`
+	HashFunctions uint64  `json:"hash_functions"`
+	Items         uint64  `json:"items"`
+	FillRatio     float64 `json:"fill_ratio"`
+	EstimatedFPP  float64 `json:"estimated_fpp"`
+}
+
+// OptimalBloomParams 根据预期元素数n和目标假阳性率p计算最优位数m和哈希函数个数k
+func OptimalBloomParams(expectedItems uint64, falsePositiveRate float64) (m uint64, k uint64) {
+	if expectedItems == 0 {
+		expectedItems = defaultExpectedItems
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = defaultFalsePositiveRate
+	}
+
+	n := float64(expectedItems)
+	// m = -n·ln(p) / (ln2)²，k = m/n · ln2
+	m = uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	// 按64位对齐
+	m = (m + 63) / 64 * 64
+	k = uint64(math.Round(float64(m) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return m, k
+}
+
+// NewBloomFilter 创建按预期元素数和假阳性率定容的布隆过滤器
+func NewBloomFilter(expectedItems uint64, falsePositiveRate float64) *BloomFilter {
+	m, k := OptimalBloomParams(expectedItems, falsePositiveRate)
 	return &BloomFilter{
-		bitset: make([]bool, size),
-		size:   size,
+		bitset: make([]uint64, m/64),
+		m:      m,
+		k:      k,
 	}
 }
 
 // Add 添加元素到布隆过滤器
 func (bf *BloomFilter) Add(item string) {
+	positions := BloomPositions(item, bf.m, bf.k)
+
 	bf.mutex.Lock()
 	defer bf.mutex.Unlock()
 
-	h1 := bf.hash1(item)
-	h2 := bf.hash2(item)
-	h3 := bf.hash3(item)
-
-	bf.bitset[h1%bf.size] = true
-	bf.bitset[h2%bf.size] = true
-	bf.bitset[h3%bf.size] = true
+	for _, pos := range positions {
+		word, mask := pos/64, uint64(1)<<(pos%64)
+		if bf.bitset[word]&mask == 0 {
+			bf.bitset[word] |= mask
+			bf.setBits++
+		}
+	}
+	bf.items++
 }
 
 // Contains 检查元素是否可能存在
 func (bf *BloomFilter) Contains(item string) bool {
+	positions := BloomPositions(item, bf.m, bf.k)
+
 	bf.mutex.RLock()
 	defer bf.mutex.RUnlock()
 
-	h1 := bf.hash1(item)
-	h2 := bf.hash2(item)
-	h3 := bf.hash3(item)
+	for _, pos := range positions {
+		if bf.bitset[pos/64]&(uint64(1)<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
 
-	return bf.bitset[h1%bf.size] && bf.bitset[h2%bf.size] && bf.bitset[h3%bf.size]
+// Stats 返回过滤器统计
+func (bf *BloomFilter) Stats() BloomFilterStats {
+	bf.mutex.RLock()
+	defer bf.mutex.RUnlock()
+
+	return NewBloomFilterStats(bf.m, bf.k, bf.setBits, bf.items)
 }
 
-// 哈希函数
-func (bf *BloomFilter) hash1(item string) uint {
-	h := fnv.New32a()
-	h.Write([]byte(item))
-	return uint(h.Sum32())
+// NewBloomFilterStats 根据位数、哈希函数个数和置位数计算统计，估算假阳性率为 填充率^k
+func NewBloomFilterStats(m, k, setBits, items uint64) BloomFilterStats {
+	fillRatio := float64(setBits) / float64(m)
+	return BloomFilterStats{
+		Bits:          m,
+		HashFunctions: k,
+		Items:         items,
+		FillRatio:     fillRatio,
+		EstimatedFPP:  math.Pow(fillRatio, float64(k)),
+	}
 }
 
-func (bf *BloomFilter) hash2(item string) uint {
-	h := fnv.New32a()
-	h.Write([]byte(item + item))
-	return uint(h.Sum32())
+// BloomPositions 用双重哈希 g_i = h1 + i·h2 计算元素在m位中的k个位置，各实现共用以保证结果一致
+func BloomPositions(item string, m, k uint64) []uint64 {
+	h1, h2 := bloomHashes(item)
+	positions := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		positions[i] = (h1 + i*h2) % m
+	}
+	return positions
 }
 
-func (bf *BloomFilter) hash3(item string) uint {
-	h := fnv.New64a()
+// bloomHashes 用128位FNV-1a哈希拆出两个64位哈希值，再经过混淆改善短键的雪崩效果
+func bloomHashes(item string) (uint64, uint64) {
+	h := fnv.New128a()
 	h.Write([]byte(item))
-	return uint(h.Sum64())
+	sum := h.Sum(nil)
+	return fmix64(binary.BigEndian.Uint64(sum[:8])), fmix64(binary.BigEndian.Uint64(sum[8:]))
+}
+
+// fmix64 MurmurHash3 的64位终结混淆函数
+func fmix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
 }
 
 // 全局布隆过滤器实例
@@ -71,9 +159,8 @@ var (
 	OriginalURLFilter *BloomFilter
 )
 
-// InitBloomFilters 初始化布隆过滤器
-func InitBloomFilters() {
-	// 预估1亿个元素，假阳性率0.01
-	ShortCodeFilter = NewBloomFilter(1000000000)
-	OriginalURLFilter = NewBloomFilter(1000000000)
+// InitBloomFilters 初始化布隆过滤器，参数为0时使用默认容量（1千万）和假阳性率（1%）
+func InitBloomFilters(expectedItems uint64, falsePositiveRate float64) {
+	ShortCodeFilter = NewBloomFilter(expectedItems, falsePositiveRate)
+	OriginalURLFilter = NewBloomFilter(expectedItems, falsePositiveRate)
 }
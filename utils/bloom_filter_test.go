@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestOptimalBloomParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     uint64
+		p     float64
+		wantM uint64
+		wantK uint64
+	}{
+		// m = ceil(-n·ln(p)/(ln2)²) 按64位对齐，k = round(m/n·ln2)
+		{name: "1k at 1%", n: 1000, p: 0.01, wantM: 9600, wantK: 7},
+		{name: "1k at 0.1%", n: 1000, p: 0.001, wantM: 14400, wantK: 10},
+		{name: "1m at 1%", n: 1000000, p: 0.01, wantM: 9585088, wantK: 7},
+		{name: "defaults", n: 0, p: 0, wantM: 95850624, wantK: 7},
+		{name: "invalid rate uses default", n: 1000, p: 1.5, wantM: 9600, wantK: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, k := OptimalBloomParams(tt.n, tt.p)
+			if m != tt.wantM || k != tt.wantK {
+				t.Errorf("OptimalBloomParams(%d, %v) = %d, %d, want %d, %d", tt.n, tt.p, m, k, tt.wantM, tt.wantK)
+			}
+			if m%64 != 0 {
+				t.Errorf("m = %d not aligned to 64 bits", m)
+			}
+		})
+	}
+}
+
+func TestBloomFilterFalsePositiveRate(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		p    float64
+	}{
+		{name: "1%", n: 10000, p: 0.01},
+		{name: "0.1%", n: 10000, p: 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf := NewBloomFilter(uint64(tt.n), tt.p)
+			for i := 0; i < tt.n; i++ {
+				bf.Add("code-" + strconv.Itoa(i))
+			}
+			for i := 0; i < tt.n; i++ {
+				if !bf.Contains("code-" + strconv.Itoa(i)) {
+					t.Fatalf("false negative for code-%d", i)
+				}
+			}
+
+			falsePositives := 0
+			const probes = 100000
+			for i := 0; i < probes; i++ {
+				if bf.Contains("other-" + strconv.Itoa(i)) {
+					falsePositives++
+				}
+			}
+			// 允许实际假阳性率比目标高一倍
+			if rate := float64(falsePositives) / probes; rate > 2*tt.p {
+				t.Errorf("false positive rate = %v, want <= %v", rate, 2*tt.p)
+			}
+
+			stats := bf.Stats()
+			if stats.Items != uint64(tt.n) {
+				t.Errorf("Stats().Items = %d, want %d", stats.Items, tt.n)
+			}
+			if stats.EstimatedFPP > 2*tt.p {
+				t.Errorf("Stats().EstimatedFPP = %v, want <= %v", stats.EstimatedFPP, 2*tt.p)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 快照格式：magic(4) | version(2) | m(8) | k(8) | setBits(8) | items(8) | completeAt(8) | data | crc32(4)，小端序。
+// completeAt 为过滤器包含全部已存储短码的时间点，恢复后只需回补此后创建或修改的短码。
+// 布隆过滤器的 data 为m位的位图，计数布隆过滤器的 data 为m个4位计数器
+const (
+	bloomSnapshotMagic    = "BLMF"
+	countingSnapshotMagic = "CBLF"
+	snapshotVersion       = 1
+)
+
+var (
+	// ErrSnapshotNotFound 快照不存在
+	ErrSnapshotNotFound = errors.New("快照不存在")
+	// ErrSnapshotMismatch 快照参数与当前过滤器不一致
+	ErrSnapshotMismatch = errors.New("快照参数与当前过滤器不一致")
+)
+
+// SnapshotStore 快照存储
+type SnapshotStore interface {
+	// Save 保存快照，覆盖旧快照
+	Save(data []byte) error
+	// Load 读取快照，不存在时返回 ErrSnapshotNotFound
+	Load() ([]byte, error)
+}
+
+// snapshotHeader 快照头
+type snapshotHeader struct {
+	Magic      [4]byte
+	Version    uint16
+	M          uint64
+	K          uint64
+	SetBits    uint64
+	Items      uint64
+	CompleteAt int64
+}
+
+// WriteSnapshot 将过滤器写为版本化二进制快照，completeAt 为过滤器完整的时间点
+func (bf *BloomFilter) WriteSnapshot(w io.Writer, completeAt time.Time) error {
+	bf.mutex.RLock()
+	defer bf.mutex.RUnlock()
+
+	return writeSnapshot(w, bloomSnapshotMagic, snapshotHeader{
+		M:          bf.m,
+		K:          bf.k,
+		SetBits:    bf.setBits,
+		Items:      bf.items,
+		CompleteAt: completeAt.UnixNano(),
+	}, bf.bitset)
+}
+
+// RestoreSnapshot 将快照合并到过滤器中，返回快照完整的时间点。快照的位数或哈希函数个数与当前过滤器不同时返回 ErrSnapshotMismatch
+func (bf *BloomFilter) RestoreSnapshot(r io.Reader) (time.Time, error) {
+	header, words, err := readSnapshot(r, bloomSnapshotMagic, bf.m, bf.k, len(bf.bitset))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	bf.mutex.Lock()
+	defer bf.mutex.Unlock()
+
+	// 合并而非替换，保留恢复前已加入的元素
+	var setBits uint64
+	for i, word := range words {
+		bf.bitset[i] |= word
+		setBits += uint64(bits.OnesCount64(bf.bitset[i]))
+	}
+	bf.setBits = setBits
+	bf.items += header.Items
+	return time.Unix(0, header.CompleteAt), nil
+}
+
+// writeSnapshot 写入快照头、数据和校验和
+func writeSnapshot(w io.Writer, magic string, header snapshotHeader, words []uint64) error {
+	crc := crc32.NewIEEE()
+	out := io.MultiWriter(w, crc)
+
+	copy(header.Magic[:], magic)
+	header.Version = snapshotVersion
+	if err := binary.Write(out, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if err := binary.Write(out, binary.LittleEndian, words); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, crc.Sum32())
+}
+
+// readSnapshot 读取并校验快照，返回快照头和长度为 wordCount 的数据
+func readSnapshot(r io.Reader, magic string, m, k uint64, wordCount int) (snapshotHeader, []uint64, error) {
+	crc := crc32.NewIEEE()
+	in := io.TeeReader(r, crc)
+
+	var header snapshotHeader
+	if err := binary.Read(in, binary.LittleEndian, &header); err != nil {
+		return header, nil, fmt.Errorf("读取快照头失败: %v", err)
+	}
+	if string(header.Magic[:]) != magic {
+		return header, nil, errors.New("快照类型不匹配")
+	}
+	if header.Version != snapshotVersion {
+		return header, nil, fmt.Errorf("不支持的快照版本: %d", header.Version)
+	}
+	if header.M != m || header.K != k {
+		return header, nil, ErrSnapshotMismatch
+	}
+
+	words := make([]uint64, wordCount)
+	if err := binary.Read(in, binary.LittleEndian, words); err != nil {
+		return header, nil, fmt.Errorf("读取快照数据失败: %v", err)
+	}
+	expected := crc.Sum32()
+	var checksum uint32
+	if err := binary.Read(r, binary.LittleEndian, &checksum); err != nil {
+		return header, nil, fmt.Errorf("读取快照校验和失败: %v", err)
+	}
+	if checksum != expected {
+		return header, nil, errors.New("快照校验和不匹配")
+	}
+	return header, words, nil
+}
+
+// FileSnapshotStore 基于本地文件的快照存储，先写临时文件再重命名保证原子替换
+type FileSnapshotStore struct {
+	path string
+}
+
+// NewFileSnapshotStore 创建文件快照存储
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{path: path}
+}
+
+// Save 保存快照
+func (s *FileSnapshotStore) Save(data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if _, err := w.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Load 读取快照
+func (s *FileSnapshotStore) Load() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// snapshotFilter 支持快照的过滤器
+type snapshotFilter interface {
+	Filter
+	WriteSnapshot(w io.Writer, completeAt time.Time) error
+	RestoreSnapshot(r io.Reader) (time.Time, error)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		newFilter func(n uint64, p float64) snapshotFilter
+		// merges 恢复时是否保留恢复前已加入的元素，计数过滤器替换全部计数
+		merges bool
+	}{
+		{name: "bloom", newFilter: func(n uint64, p float64) snapshotFilter { return NewBloomFilter(n, p) }, merges: true},
+		{name: "counting", newFilter: func(n uint64, p float64) snapshotFilter { return NewCountingBloomFilter(n, p) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.newFilter(1000, 0.01)
+			for i := 0; i < 500; i++ {
+				original.Add("code-" + strconv.Itoa(i))
+			}
+			completeAt := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+			var buf bytes.Buffer
+			if err := original.WriteSnapshot(&buf, completeAt); err != nil {
+				t.Fatalf("WriteSnapshot() error = %v", err)
+			}
+			data := buf.Bytes()
+
+			restored := tt.newFilter(1000, 0.01)
+			restored.Add("before-restore")
+			got, err := restored.RestoreSnapshot(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("RestoreSnapshot() error = %v", err)
+			}
+			if !got.Equal(completeAt) {
+				t.Errorf("RestoreSnapshot() time = %v, want %v", got, completeAt)
+			}
+			for i := 0; i < 500; i++ {
+				if !restored.Contains("code-" + strconv.Itoa(i)) {
+					t.Fatalf("code-%d missing after restore", i)
+				}
+			}
+			if got := restored.Contains("before-restore"); got != tt.merges {
+				t.Errorf("Contains(item added before restore) = %v, want %v", got, tt.merges)
+			}
+			want := original.Stats().Items
+			if tt.merges {
+				want++
+			}
+			if got := restored.Stats().Items; got != want {
+				t.Errorf("Stats().Items = %d, want %d", got, want)
+			}
+
+			corrupted := append([]byte(nil), data...)
+			corrupted[len(corrupted)/2] ^= 0xff
+			if _, err := tt.newFilter(1000, 0.01).RestoreSnapshot(bytes.NewReader(corrupted)); err == nil {
+				t.Errorf("RestoreSnapshot() of corrupted data succeeded")
+			}
+			if _, err := tt.newFilter(1000, 0.01).RestoreSnapshot(bytes.NewReader(data[:len(data)-1])); err == nil {
+				t.Errorf("RestoreSnapshot() of truncated data succeeded")
+			}
+			if _, err := tt.newFilter(2000, 0.01).RestoreSnapshot(bytes.NewReader(data)); !errors.Is(err, ErrSnapshotMismatch) {
+				t.Errorf("RestoreSnapshot() with other params error = %v, want %v", err, ErrSnapshotMismatch)
+			}
+		})
+	}
+}
+
+func TestSnapshotTypeMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewCountingBloomFilter(1000, 0.01).WriteSnapshot(&buf, time.Now()); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	if _, err := NewBloomFilter(1000, 0.01).RestoreSnapshot(&buf); err == nil {
+		t.Errorf("Bloom filter restored a counting filter snapshot")
+	}
+}
+
+func TestFileSnapshotStore(t *testing.T) {
+	store := NewFileSnapshotStore(filepath.Join(t.TempDir(), "snapshots", "bloom.snap"))
+	if _, err := store.Load(); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Fatalf("Load() before Save error = %v, want %v", err, ErrSnapshotNotFound)
+	}
+	for _, data := range [][]byte{[]byte("first"), []byte("second")} {
+		if err := store.Save(data); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+		got, err := store.Load()
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("Load() = %q, %v, want %q", got, err, data)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Blocklist 屏蔽词检查，用于过滤不当的自定义别名和生成短码
+type Blocklist interface {
+	Blocked(code string) bool
+}
+
+// WordBlocklist 基于词表的屏蔽词检查：忽略大小写、连字符和下划线后，短码包含任一屏蔽词即被屏蔽
+type WordBlocklist struct {
+	words []string
+}
+
+// NewWordBlocklist 创建词表屏蔽检查，忽略空白词
+func NewWordBlocklist(words []string) *WordBlocklist {
+	b := &WordBlocklist{}
+	for _, word := range words {
+		if word = normalizeBlockWord(word); word != "" {
+			b.words = append(b.words, word)
+		}
+	}
+	return b
+}
+
+// ReadWordList 从文件读取词表，每行一个词，忽略空行和 # 开头的注释行
+func ReadWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开词表文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取词表文件失败: %v", err)
+	}
+	return words, nil
+}
+
+// Len 屏蔽词个数
+func (b *WordBlocklist) Len() int {
+	return len(b.words)
+}
+
+// Blocked 短码是否包含屏蔽词
+func (b *WordBlocklist) Blocked(code string) bool {
+	code = normalizeBlockWord(code)
+	for _, word := range b.words {
+		if strings.Contains(code, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBlockWord 转为小写并去掉连字符和下划线，避免用分隔符绕过屏蔽
+func normalizeBlockWord(word string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(word)))
+}
+
+// CodePolicy 短码策略：保留词（如路由前缀）不能用作短码，包含屏蔽词的自定义别名和生成短码会被拒绝
+type CodePolicy struct {
+	mu        sync.RWMutex
+	reserved  map[string]bool // 小写的保留词
+	blocklist Blocklist
+}
+
+// NewCodePolicy 创建短码策略，blocklist 为nil时不检查屏蔽词
+func NewCodePolicy(reserved []string, blocklist Blocklist) *CodePolicy {
+	p := &CodePolicy{reserved: make(map[string]bool), blocklist: blocklist}
+	p.Reserve(reserved...)
+	return p
+}
+
+// Reserve 追加保留词
+func (p *CodePolicy) Reserve(words ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			p.reserved[word] = true
+		}
+	}
+}
+
+// Reserved 短码是否为保留词，忽略大小写
+func (p *CodePolicy) Reserved(code string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.reserved[strings.ToLower(code)]
+}
+
+// Blocked 短码是否包含屏蔽词
+func (p *CodePolicy) Blocked(code string) bool {
+	return p.blocklist != nil && p.blocklist.Blocked(code)
+}
+
+// Allowed 短码既不是保留词也不包含屏蔽词
+func (p *CodePolicy) Allowed(code string) bool {
+	return !p.Reserved(code) && !p.Blocked(code)
+}
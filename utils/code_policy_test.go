@@ -0,0 +1,70 @@
+package utils
+
+import "testing"
+
+func TestWordBlocklist(t *testing.T) {
+	blocklist := NewWordBlocklist([]string{"Bad", " evil ", "", "no-go"})
+	if blocklist.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", blocklist.Len())
+	}
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{code: "bad", want: true},
+		{code: "BAD", want: true},
+		{code: "xxBaDxx", want: true},
+		{code: "evil123", want: true},
+		{code: "b-a_d", want: true},
+		{code: "nogo", want: true},
+		{code: "no_go", want: true},
+		{code: "ba", want: false},
+		{code: "good", want: false},
+		{code: "b4d", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := blocklist.Blocked(tt.code); got != tt.want {
+				t.Errorf("Blocked(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodePolicy(t *testing.T) {
+	policy := NewCodePolicy([]string{"API", " healthz "}, NewWordBlocklist([]string{"bad"}))
+	policy.Reserve("readyz")
+	tests := []struct {
+		code         string
+		wantReserved bool
+		wantBlocked  bool
+	}{
+		{code: "api", wantReserved: true},
+		{code: "Api", wantReserved: true},
+		{code: "healthz", wantReserved: true},
+		{code: "READYZ", wantReserved: true},
+		// 保留词只匹配整个短码
+		{code: "api2"},
+		{code: "myapi"},
+		{code: "Bad", wantBlocked: true},
+		{code: "notbad1", wantBlocked: true},
+		{code: "abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := policy.Reserved(tt.code); got != tt.wantReserved {
+				t.Errorf("Reserved(%q) = %v, want %v", tt.code, got, tt.wantReserved)
+			}
+			if got := policy.Blocked(tt.code); got != tt.wantBlocked {
+				t.Errorf("Blocked(%q) = %v, want %v", tt.code, got, tt.wantBlocked)
+			}
+			if got, want := policy.Allowed(tt.code), !tt.wantReserved && !tt.wantBlocked; got != want {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.code, got, want)
+			}
+		})
+	}
+
+	if NewCodePolicy(nil, nil).Blocked("bad") {
+		t.Errorf("Blocked() without blocklist = true")
+	}
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// 每个计数器占4位，一个字存放16个计数器
+	counterBits    = 4
+	countersPerKey = 64 / counterBits
+	counterMax     = 1<<counterBits - 1
+)
+
+// CountingBloomFilter 计数布隆过滤器，每个位置使用4位计数器，支持删除元素。
+// 计数器达到上限15后不再增减，避免溢出导致误删；内存占用是同参数布隆过滤器的4倍
+type CountingBloomFilter struct {
+	mutex    sync.RWMutex
+	counters []uint64
+	m        uint64 // 计数器个数
+	k        uint64 // 哈希函数个数
+	nonZero  uint64 // 非零计数器个数
+	items    uint64 // 当前元素数（添加减去删除）
+}
+
+// NewCountingBloomFilter 创建按预期元素数和假阳性率定容的计数布隆过滤器
+func NewCountingBloomFilter(expectedItems uint64, falsePositiveRate float64) *CountingBloomFilter {
+	m, k := OptimalBloomParams(expectedItems, falsePositiveRate)
+	return &CountingBloomFilter{
+		counters: make([]uint64, m/countersPerKey),
+		m:        m,
+		k:        k,
+	}
+}
+
+// Add 添加元素，同一元素的重复位置只计数一次
+func (cf *CountingBloomFilter) Add(item string) {
+	positions := uniquePositions(BloomPositions(item, cf.m, cf.k))
+
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+
+	for _, pos := range positions {
+		count := cf.get(pos)
+		if count == counterMax {
+			continue
+		}
+		if count == 0 {
+			cf.nonZero++
+		}
+		cf.set(pos, count+1)
+	}
+	cf.items++
+}
+
+// Remove 删除元素，只能删除确实添加过的元素，否则会造成其他元素漏判。
+// 与 Add 一致，重复位置只减一次
+func (cf *CountingBloomFilter) Remove(item string) {
+	positions := uniquePositions(BloomPositions(item, cf.m, cf.k))
+
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+
+	// 任一计数器为0说明元素不存在，不做修改
+	for _, pos := range positions {
+		if cf.get(pos) == 0 {
+			return
+		}
+	}
+
+	for _, pos := range positions {
+		count := cf.get(pos)
+		if count == counterMax {
+			continue
+		}
+		if count == 1 {
+			cf.nonZero--
+		}
+		cf.set(pos, count-1)
+	}
+	if cf.items > 0 {
+		cf.items--
+	}
+}
+
+// Contains 检查元素是否可能存在
+func (cf *CountingBloomFilter) Contains(item string) bool {
+	positions := BloomPositions(item, cf.m, cf.k)
+
+	cf.mutex.RLock()
+	defer cf.mutex.RUnlock()
+
+	for _, pos := range positions {
+		if cf.get(pos) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats 返回过滤器统计，填充率为非零计数器占比
+func (cf *CountingBloomFilter) Stats() BloomFilterStats {
+	cf.mutex.RLock()
+	defer cf.mutex.RUnlock()
+
+	return NewBloomFilterStats(cf.m, cf.k, cf.nonZero, cf.items)
+}
+
+// WriteSnapshot 将过滤器写为版本化二进制快照，completeAt 为过滤器完整的时间点
+func (cf *CountingBloomFilter) WriteSnapshot(w io.Writer, completeAt time.Time) error {
+	cf.mutex.RLock()
+	defer cf.mutex.RUnlock()
+
+	return writeSnapshot(w, countingSnapshotMagic, snapshotHeader{
+		M:          cf.m,
+		K:          cf.k,
+		SetBits:    cf.nonZero,
+		Items:      cf.items,
+		CompleteAt: completeAt.UnixNano(),
+	}, cf.counters)
+}
+
+// RestoreSnapshot 用快照替换过滤器的全部计数，返回快照完整的时间点。
+// 与普通布隆过滤器不同，计数不能合并：同一元素在快照和当前状态中各计一次后，单次删除无法清零
+func (cf *CountingBloomFilter) RestoreSnapshot(r io.Reader) (time.Time, error) {
+	header, words, err := readSnapshot(r, countingSnapshotMagic, cf.m, cf.k, len(cf.counters))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	cf.mutex.Lock()
+	defer cf.mutex.Unlock()
+
+	copy(cf.counters, words)
+	var nonZero uint64
+	for pos := uint64(0); pos < cf.m; pos++ {
+		if cf.get(pos) > 0 {
+			nonZero++
+		}
+	}
+	cf.nonZero = nonZero
+	cf.items = header.Items
+	return time.Unix(0, header.CompleteAt), nil
+}
+
+// uniquePositions 去掉重复的位置，两个哈希位置相同时计数器只能增减一次
+func uniquePositions(positions []uint64) []uint64 {
+	unique := positions[:0]
+	for _, pos := range positions {
+		if !slices.Contains(unique, pos) {
+			unique = append(unique, pos)
+		}
+	}
+	return unique
+}
+
+// get 读取第pos个计数器，调用方需持有锁
+func (cf *CountingBloomFilter) get(pos uint64) uint64 {
+	shift := (pos % countersPerKey) * counterBits
+	return (cf.counters[pos/countersPerKey] >> shift) & counterMax
+}
+
+// set 写入第pos个计数器，调用方需持有锁
+func (cf *CountingBloomFilter) set(pos uint64, count uint64) {
+	shift := (pos % countersPerKey) * counterBits
+	word := &cf.counters[pos/countersPerKey]
+	*word = *word&^(counterMax<<shift) | count<<shift
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCountingBloomFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		// ops 依次执行：+x 添加，-x 删除
+		ops       []string
+		contains  []string
+		missing   []string
+		wantItems uint64
+	}{
+		{name: "add", ops: []string{"+a", "+b"}, contains: []string{"a", "b"}, wantItems: 2},
+		{name: "remove", ops: []string{"+a", "+b", "-a"}, contains: []string{"b"}, missing: []string{"a"}, wantItems: 1},
+		{name: "added twice needs two removes", ops: []string{"+a", "+a", "-a"}, contains: []string{"a"}, wantItems: 1},
+		{name: "remove unknown is ignored", ops: []string{"+a", "-b"}, contains: []string{"a"}, wantItems: 1},
+		{name: "remove all", ops: []string{"+a", "+b", "-b", "-a"}, missing: []string{"a", "b"}, wantItems: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cf := NewCountingBloomFilter(1000, 0.01)
+			for _, op := range tt.ops {
+				if op[0] == '+' {
+					cf.Add(op[1:])
+				} else {
+					cf.Remove(op[1:])
+				}
+			}
+			for _, item := range tt.contains {
+				if !cf.Contains(item) {
+					t.Errorf("Contains(%q) = false, want true", item)
+				}
+			}
+			for _, item := range tt.missing {
+				if cf.Contains(item) {
+					t.Errorf("Contains(%q) = true, want false", item)
+				}
+			}
+			if stats := cf.Stats(); stats.Items != tt.wantItems {
+				t.Errorf("Stats().Items = %d, want %d", stats.Items, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestCountingBloomFilterRemoveKeepsOthers(t *testing.T) {
+	cf := NewCountingBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		cf.Add("code-" + strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i += 2 {
+		cf.Remove("code-" + strconv.Itoa(i))
+	}
+	// 删除元素不能造成其他元素漏判
+	for i := 1; i < 1000; i += 2 {
+		if !cf.Contains("code-" + strconv.Itoa(i)) {
+			t.Fatalf("false negative for code-%d after removing others", i)
+		}
+	}
+	removed := 0
+	for i := 0; i < 1000; i += 2 {
+		if !cf.Contains("code-" + strconv.Itoa(i)) {
+			removed++
+		}
+	}
+	if removed < 450 {
+		t.Errorf("only %d of 500 removed items rejected", removed)
+	}
+}
+
+func TestCountingBloomFilterSaturation(t *testing.T) {
+	// 计数器饱和后不再减少，避免误删
+	cf := NewCountingBloomFilter(1000, 0.01)
+	for i := 0; i < counterMax+5; i++ {
+		cf.Add("hot")
+	}
+	for i := 0; i < counterMax+5; i++ {
+		cf.Remove("hot")
+	}
+	if !cf.Contains("hot") {
+		t.Errorf("saturated item removed")
+	}
+}
+
+func TestCountingBloomFilterDuplicatePositions(t *testing.T) {
+	// 64个计数器、44个哈希函数，几乎每个元素都有重复位置
+	cf := NewCountingBloomFilter(1, 0.5)
+	var item string
+	for i := 0; item == ""; i++ {
+		positions := BloomPositions("code-"+strconv.Itoa(i), cf.m, cf.k)
+		if len(uniquePositions(positions)) < len(positions) {
+			item = "code-" + strconv.Itoa(i)
+		}
+	}
+
+	cf.Add(item)
+	for _, pos := range BloomPositions(item, cf.m, cf.k) {
+		if count := cf.get(pos); count != 1 {
+			t.Fatalf("counter %d = %d after one Add, want 1", pos, count)
+		}
+	}
+	cf.Remove(item)
+	if cf.Contains(item) {
+		t.Errorf("Contains() = true after Remove")
+	}
+	if cf.nonZero != 0 {
+		t.Errorf("non-zero counters = %d after Remove, want 0", cf.nonZero)
+	}
+}
+
+func TestCountingBloomFilterRestoreReplaces(t *testing.T) {
+	original := NewCountingBloomFilter(1000, 0.01)
+	original.Add("kept")
+	var buf bytes.Buffer
+	if err := original.WriteSnapshot(&buf, time.Now()); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	// 恢复前已加入的元素被替换，快照中的元素恢复后删除一次即可清零
+	cf := NewCountingBloomFilter(1000, 0.01)
+	cf.Add("kept")
+	cf.Add("dropped")
+	if _, err := cf.RestoreSnapshot(&buf); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if cf.Contains("dropped") {
+		t.Errorf("Contains(dropped) = true, want state replaced")
+	}
+	cf.Remove("kept")
+	if cf.Contains("kept") {
+		t.Errorf("Contains(kept) = true after single Remove, want counts replaced not added")
+	}
+	if cf.items != 0 || cf.nonZero != 0 {
+		t.Errorf("items, non-zero counters = %d, %d, want empty", cf.items, cf.nonZero)
+	}
+}
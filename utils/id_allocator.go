@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"sync"
+)
+
+// IDAllocator 单调递增的ID分配器
+type IDAllocator interface {
+	NextID() (uint64, error)
+}
+
+// BlockSource 一次预留 size 个连续ID，返回第一个ID
+type BlockSource func(size uint64) (uint64, error)
+
+// BlockAllocator 按号段分配ID：每次从数据库或Redis预留一段ID，用完后再取下一段，
+// 大部分分配不需要访问存储。实例重启时未用完的号段会被跳过
+type BlockAllocator struct {
+	source BlockSource
+	size   uint64
+
+	mu   sync.Mutex
+	next uint64
+	end  uint64 // 当前号段的结束位置（不含）
+}
+
+// NewBlockAllocator 创建号段分配器，size 为每次预留的ID个数，默认100
+func NewBlockAllocator(source BlockSource, size uint64) *BlockAllocator {
+	if size == 0 {
+		size = 100
+	}
+	return &BlockAllocator{source: source, size: size}
+}
+
+// NextID 分配下一个ID
+func (a *BlockAllocator) NextID() (uint64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.next == a.end {
+		start, err := a.source(a.size)
+		if err != nil {
+			return 0, err
+		}
+		a.next, a.end = start, start+a.size
+	}
+
+	id := a.next
+	a.next++
+	return id, nil
+}
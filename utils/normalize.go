@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// NormalizeURL 使用默认规则规范化URL，见 URLNormalizer。无法解析的URL原样返回
+func NormalizeURL(rawURL string) string {
+	return (&URLNormalizer{}).Normalize(rawURL)
+}
+
+// URLNormalizer URL规范化规则，使等价的写法得到相同的规范形式：
+// 协议和主机名转为小写，去掉默认端口和主机名末尾的点，空路径补为 "/"，
+// 非根路径去掉末尾的 "/"，百分号编码统一（非保留字符解码，其余十六进制转为大写），
+// 查询参数去掉空参数和跟踪参数后按参数名排序。片段默认保留，单页应用常用片段区分页面
+type URLNormalizer struct {
+	// StripParams 需要去掉的查询参数名（忽略大小写），以 * 结尾时按前缀匹配，例如 utm_*
+	StripParams []string
+	// KeepTrailingSlash 保留路径末尾的 "/"
+	KeepTrailingSlash bool
+	// StripFragment 去掉片段（# 之后的部分）
+	StripFragment bool
+}
+
+// Normalize 返回URL的规范形式，无法解析或没有主机名的URL原样返回
+func (n *URLNormalizer) Normalize(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		// IPv6地址需要保留方括号
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+
+	path := normalizeEscapes(u.EscapedPath())
+	if path == "" {
+		path = "/"
+	} else if !n.KeepTrailingSlash && len(path) > 1 {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(u.Scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+	b.WriteString(path)
+	if query := n.normalizeQuery(u.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+	if fragment := u.EscapedFragment(); fragment != "" && !n.StripFragment {
+		b.WriteByte('#')
+		b.WriteString(normalizeEscapes(fragment))
+	}
+	return b.String()
+}
+
+// normalizeQuery 去掉空参数和需要去掉的参数，统一编码后按参数名排序，同名参数保持原有顺序
+func (n *URLNormalizer) normalizeQuery(rawQuery string) string {
+	type param struct {
+		key  string
+		pair string
+	}
+	var params []param
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		if decoded, err := url.QueryUnescape(key); err == nil {
+			key = decoded
+		}
+		if n.stripped(key) {
+			continue
+		}
+		params = append(params, param{key: key, pair: normalizeEscapes(pair)})
+	}
+
+	sort.SliceStable(params, func(i, j int) bool {
+		return params[i].key < params[j].key
+	})
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.pair
+	}
+	return strings.Join(pairs, "&")
+}
+
+// stripped 查询参数是否需要去掉
+func (n *URLNormalizer) stripped(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range n.StripParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEscapes 统一百分号编码：非保留字符（字母、数字、-._~）解码，其余编码的十六进制转为大写
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// URLHost 返回URL的主机名（小写，不含端口和末尾的点），无法解析时返回空字符串
+func URLHost(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
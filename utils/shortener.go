@@ -1,70 +1,179 @@
 package utils
 
 import (
-	"crypto/md5"
-	"encoding/hex"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
 	"math/rand"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-const (
-	// 短链码字符集
-	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	// 短链码长度
-	shortCodeLength = 7
-)
+// DefaultShortCodeLength 默认短码长度
+const DefaultShortCodeLength = 7
 
 var (
 	seededRand *rand.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
 	mutex      sync.Mutex
 )
 
-// GenerateShortCode 生成短链码
-func GenerateShortCode(originalURL string) string {
-	// 使用MD5哈希
-	hasher := md5.New()
-	hasher.Write([]byte(originalURL))
-	hasher.Write([]byte(time.Now().String()))
-	md5Hash := hex.EncodeToString(hasher.Sum(nil))
-
-	// 取前7位作为短码
-	shortCode := md5Hash[:shortCodeLength]
-
-	// 确保只包含允许的字符
-	for i, c := range shortCode {
-		if !strings.ContainsRune(charset, c) {
-			shortCode = shortCode[:i] + string(charset[seededRand.Intn(len(charset))]) + shortCode[i+1:]
-		}
-	}
+// ShortCodeGenerator 短码生成策略
+type ShortCodeGenerator interface {
+	// Generate 为原始URL生成短码，attempt 为因短码冲突而重试的次数（从0开始）
+	Generate(originalURL string, attempt int) (string, error)
+}
+
+// RandomGenerator 从字符集中随机选取字符生成短码
+type RandomGenerator struct {
+	alphabet *Alphabet
+	length   int
+}
 
-	return shortCode
+// NewRandomGenerator 创建随机短码生成器
+func NewRandomGenerator(alphabet *Alphabet, length int) *RandomGenerator {
+	return &RandomGenerator{alphabet: alphabet, length: length}
 }
 
-// GenerateRandomShortCode 生成随机短链码
-func GenerateRandomShortCode() string {
+// Generate 生成随机短码
+func (g *RandomGenerator) Generate(string, int) (string, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	b := make([]byte, shortCodeLength)
+	chars := g.alphabet.Chars()
+	b := make([]byte, g.length)
 	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
+		b[i] = chars[seededRand.Intn(len(chars))]
 	}
-	return string(b)
+	return string(b), nil
 }
 
-// IsValidShortCode 验证短链码是否合法
-func IsValidShortCode(code string) bool {
-	if len(code) != shortCodeLength {
-		return false
+// ambiguousChars 容易混淆的字符
+const ambiguousChars = "0Oo1Il"
+
+// NewReadableGenerator 创建易读短码生成器：从字符集中去掉易混淆字符（0、O、o、1、I、l）后随机生成
+func NewReadableGenerator(alphabet *Alphabet, length int) (*RandomGenerator, error) {
+	readable := make([]byte, 0, len(alphabet.Chars()))
+	for i := 0; i < len(alphabet.Chars()); i++ {
+		if c := alphabet.Chars()[i]; strings.IndexByte(ambiguousChars, c) < 0 {
+			readable = append(readable, c)
+		}
 	}
+	filtered, err := NewAlphabet(string(readable))
+	if err != nil {
+		return nil, err
+	}
+	return NewRandomGenerator(filtered, length), nil
+}
 
-	for _, c := range code {
-		if !strings.ContainsRune(charset, c) {
+// HashGenerator 确定性短码生成器：对调用方传入的规范形式URL计算带密钥的哈希 HMAC-SHA256(secret, salt|url|attempt)，
+// 取前8字节对编码空间取模后编码。同一URL在任意实例上都得到相同的短码，无需查询存储；
+// 第 n 次冲突使用 attempt=n 得到序列中的下一个短码
+type HashGenerator struct {
+	alphabet *Alphabet
+	length   int
+	secret   []byte
+	salt     string
+}
+
+// NewHashGenerator 创建确定性短码生成器，secret 为HMAC密钥，salt 为混入消息的盐值，
+// 修改任意一个都会改变URL对应的短码
+func NewHashGenerator(alphabet *Alphabet, length int, secret, salt string) *HashGenerator {
+	return &HashGenerator{alphabet: alphabet, length: length, secret: []byte(secret), salt: salt}
+}
+
+// Generate 根据URL和冲突次数生成短码，URL按原样参与哈希，不再规范化
+func (g *HashGenerator) Generate(canonicalURL string, attempt int) (string, error) {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(g.salt))
+	mac.Write([]byte{0})
+	mac.Write([]byte(canonicalURL))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.Itoa(attempt)))
+	sum := mac.Sum(nil)
+
+	id := binary.BigEndian.Uint64(sum[:8]) % g.alphabet.Space(g.length)
+	return g.alphabet.Encode(id, g.length)
+}
+
+// Deterministic 同一URL总是生成相同的短码序列
+func (g *HashGenerator) Deterministic() bool {
+	return true
+}
+
+// SequenceGenerator 基于自增ID的短码生成器：ID编码为固定长度的短码，
+// 开启混淆时先对ID做可逆置换，不同ID生成的短码必然不同，无需查重
+type SequenceGenerator struct {
+	ids       IDAllocator
+	alphabet  *Alphabet
+	length    int
+	obfuscate bool
+	key       uint64
+}
+
+// NewSequenceGenerator 创建基于自增ID的短码生成器
+func NewSequenceGenerator(ids IDAllocator, alphabet *Alphabet, length int, obfuscate bool, key uint64) *SequenceGenerator {
+	return &SequenceGenerator{ids: ids, alphabet: alphabet, length: length, obfuscate: obfuscate, key: key}
+}
+
+// Generate 分配下一个ID并编码为短码
+func (g *SequenceGenerator) Generate(string, int) (string, error) {
+	id, err := g.ids.NextID()
+	if err != nil {
+		return "", err
+	}
+
+	space := g.alphabet.Space(g.length)
+	if id >= space {
+		return "", ErrIDOutOfRange
+	}
+	if g.obfuscate {
+		id = PermuteID(id, g.key, space)
+	}
+	return g.alphabet.Encode(id, g.length)
+}
+
+// CodeRules 短码校验规则：长度范围和字符集
+type CodeRules struct {
+	Alphabet  *Alphabet
+	MinLength int
+	MaxLength int
+	// InnerChars 字符集之外、只能出现在中间（不能在首尾）的字符，如连字符和下划线
+	InnerChars string
+}
+
+// Valid 短码是否符合规则
+func (r CodeRules) Valid(code string) bool {
+	if len(code) < r.MinLength || len(code) > r.MaxLength {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if r.Alphabet.Contains(code[i : i+1]) {
+			continue
+		}
+		if i == 0 || i == len(code)-1 || strings.IndexByte(r.InnerChars, code[i]) < 0 {
 			return false
 		}
 	}
-
 	return true
 }
+
+// DefaultCodeRules 默认生成短码规则：base62字符集，固定7位
+func DefaultCodeRules() CodeRules {
+	return CodeRules{
+		Alphabet:  MustAlphabet(Base62Chars),
+		MinLength: DefaultShortCodeLength,
+		MaxLength: DefaultShortCodeLength,
+	}
+}
+
+// DefaultAliasRules 默认自定义别名规则：base62字符集，中间允许连字符和下划线，3到32位
+func DefaultAliasRules() CodeRules {
+	return CodeRules{
+		Alphabet:   MustAlphabet(Base62Chars),
+		MinLength:  3,
+		MaxLength:  32,
+		InnerChars: "-_",
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import "testing"
+
+func TestHashGenerator(t *testing.T) {
+	g := NewHashGenerator(MustAlphabet(Base62Chars), 7, "secret", "")
+	generate := func(url string, attempt int) string {
+		t.Helper()
+		code, err := g.Generate(url, attempt)
+		if err != nil {
+			t.Fatalf("Generate(%q, %d) error = %v", url, attempt, err)
+		}
+		return code
+	}
+
+	url := "https://example.com/a"
+	if generate(url, 0) != generate(url, 0) {
+		t.Errorf("Generate() not deterministic")
+	}
+	if generate(url, 0) == generate(url, 1) {
+		t.Errorf("Generate() ignores attempt")
+	}
+	// 调用方传入的已是规范形式，生成器不再按默认规则规范化
+	if NormalizeURL("https://Example.com/a/") != url {
+		t.Fatalf("NormalizeURL() no longer folds the test URLs")
+	}
+	if generate("https://Example.com/a/", 0) == generate(url, 0) {
+		t.Errorf("Generate() normalized its input")
+	}
+	other := NewHashGenerator(MustAlphabet(Base62Chars), 7, "other", "")
+	if code, _ := other.Generate(url, 0); code == generate(url, 0) {
+		t.Errorf("Generate() ignores secret")
+	}
+}
+
+func TestCodeRulesValid(t *testing.T) {
+	rules := CodeRules{Alphabet: MustAlphabet(Base62Chars), MinLength: 3, MaxLength: 8, InnerChars: "-_"}
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{name: "alphanumeric", code: "abc123", want: true},
+		{name: "inner hyphen", code: "my-link", want: true},
+		{name: "inner underscore", code: "my_link", want: true},
+		{name: "adjacent inner chars", code: "a-_b", want: true},
+		{name: "leading hyphen", code: "-abc"},
+		{name: "trailing hyphen", code: "abc-"},
+		{name: "leading underscore", code: "_abc"},
+		{name: "trailing underscore", code: "abc_"},
+		{name: "other symbol", code: "ab.cd"},
+		{name: "space", code: "ab cd"},
+		{name: "non ascii", code: "abc链接"},
+		{name: "too short", code: "ab"},
+		{name: "too long", code: "abcdefghi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Valid(tt.code); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+
+	// 未配置 InnerChars 时中间也不允许分隔符
+	if DefaultCodeRules().Valid("abc-def") {
+		t.Errorf("DefaultCodeRules().Valid(%q) = true", "abc-def")
+	}
+}